@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// adaptiveLimiter limits the number of in-flight scrapes with additive-increase/multiplicative-decrease
+// (AIMD): every healthy completion raises the limit by 1/limit (about +1 per round of requests),
+// while an overload signal or a latency spike halves it. The limit stays between 1 and maxLimit
+type adaptiveLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    float64
+	maxLimit float64
+	inFlight int
+	// baseline is the lowest latency observed, used to detect latency degradation
+	baseline time.Duration
+}
+
+// latencyDegradation is how many times the baseline latency a request may take before it counts as degraded
+const latencyDegradation = 3
+
+// newAdaptiveLimiter creates a limiter starting at a single in-flight request
+func newAdaptiveLimiter(maxLimit int) *adaptiveLimiter {
+	l := &adaptiveLimiter{limit: 1, maxLimit: float64(maxLimit)}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// acquire blocks until another request may be in flight
+func (l *adaptiveLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.inFlight >= int(l.limit) {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+// release records the outcome of a request and adjusts the limit
+func (l *adaptiveLimiter) release(latency time.Duration, overloaded bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+
+	if !overloaded && (l.baseline == 0 || latency < l.baseline) {
+		l.baseline = latency
+	}
+
+	previous := int(l.limit)
+	if overloaded || latency > latencyDegradation*l.baseline {
+		l.limit = max(1, l.limit/2)
+	} else {
+		l.limit = min(l.maxLimit, l.limit+1/l.limit)
+	}
+	if current := int(l.limit); current != previous {
+		log.Printf("Adaptive concurrency: %d -> %d workers (latency %v, baseline %v, overloaded %v)", previous, current, latency.Round(time.Millisecond), l.baseline.Round(time.Millisecond), overloaded)
+	}
+
+	l.cond.Broadcast()
+}
+
+// reset drops the limit back to a single in-flight request, e.g. after the target throttled the pool,
+// so concurrency ramps up again gradually
+func (l *adaptiveLimiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if previous := int(l.limit); previous > 1 {
+		log.Printf("Adaptive concurrency: %d -> 1 workers (throttled)", previous)
+	}
+	l.limit = 1
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// annotationEscaper escapes the message of a GitHub Actions workflow command
+var annotationEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// annotationPropertyEscaper escapes a property value of a GitHub Actions workflow command, e.g. the title
+var annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+// writeAnnotation writes a GitHub Actions annotation such as "::error title=Scrape failed::message".
+// level is notice, warning or error
+func writeAnnotation(w io.Writer, level, title, message string) {
+	fmt.Fprintf(w, "::%s title=%s::%s\n", level, annotationPropertyEscaper.Replace(title), annotationEscaper.Replace(message))
+}
+
+// writeAnnotations writes the failures of a run as error annotations and the selector health warnings
+// as warning annotations, so they show up inline in a GitHub Actions run
+func writeAnnotations(w io.Writer, failures []scrapeFailure, healthWarnings []string) {
+	for _, f := range failures {
+		writeAnnotation(w, "error", "Scrape failed ("+errorCategory(f.Err)+")", fmt.Sprintf("%s: %v", f.URL, f.Err))
+	}
+	for _, warning := range healthWarnings {
+		writeAnnotation(w, "warning", "Selector health", warning)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditRecord is one scrape attempt in the audit log
+type auditRecord struct {
+	Timestamp  time.Time `json:"timestamp"`
+	URL        string    `json:"url"`
+	Attempt    int       `json:"attempt"`
+	Status     int       `json:"status"`
+	Category   string    `json:"category,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// auditLog writes a machine-readable NDJSON record of every scrape attempt, one JSON object per line.
+// It is safe for concurrent use by the workers
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// newAuditLog creates (or truncates) the audit log file
+func newAuditLog(filename string) (*auditLog, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// record appends an attempt to the audit log. A nil auditLog records nothing
+func (a *auditLog) record(url string, attempt int, started time.Time, err error) error {
+	if a == nil {
+		return nil
+	}
+
+	rec := auditRecord{
+		Timestamp:  started,
+		URL:        url,
+		Attempt:    attempt,
+		Status:     http.StatusOK,
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		rec.Status = 0
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) {
+			rec.Status = statusErr.StatusCode
+		}
+		rec.Category = errorCategory(err)
+		rec.Error = err.Error()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enc.Encode(rec)
+}
+
+// Close closes the audit log file
+func (a *auditLog) Close() error {
+	return a.file.Close()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Change types of the rows written with -baseline
+const (
+	changeAdded   = "added"
+	changeChanged = "changed"
+)
+
+// baselineIgnoredColumns differ between any two runs or describe the comparison itself, so they don't
+// count as a change. Installs As Of is usually the fetch time too; a new figure changes Installs anyway
+var baselineIgnoredColumns = []string{"Fetched At", "Installs As Of", "Change Type", "Changed Fields"}
+
+// baselineColumns returns the column names a row is compared on: the output columns and passthrough columns
+// under their own names, before -rename
+func baselineColumns() []string {
+	return append(slices.Clone(outputHeaders), passthroughColumns...)
+}
+
+// readBaseline reads a previous output for -baseline into its rows by plugin slug, each row mapping column
+// names to values. JSON and NDJSON outputs (.json, .ndjson, optionally gzipped) are read as with -replay,
+// anything else as a results CSV; a CSV written with -rename is mapped back to the original column names
+func readBaseline(filename string) (map[string]map[string]string, error) {
+	columns := baselineColumns()
+	rows := make(map[string]map[string]string)
+
+	name := strings.TrimSuffix(filename, ".gz")
+	if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".ndjson") {
+		records, err := readReplayFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, meta := range records {
+			rows[pluginKey(meta)] = rowValues(columns, pluginRow(meta))
+		}
+		return rows, nil
+	}
+
+	records, err := readCSVFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return rows, nil
+	}
+	original := make(map[string]string, len(columns))
+	for i, renamed := range headerRow() {
+		original[renamed] = columns[i]
+	}
+	header := make([]string, len(records[0]))
+	for i, h := range records[0] {
+		header[i] = h
+		if name, ok := original[h]; ok {
+			header[i] = name
+		}
+	}
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = value
+			}
+		}
+		rows[mergeKey(row)] = row
+	}
+	return rows, nil
+}
+
+// rowValues maps column names to the values of a row
+func rowValues(columns, values []string) map[string]string {
+	row := make(map[string]string, len(columns))
+	for i, name := range columns {
+		row[name] = values[i]
+	}
+	return row
+}
+
+// diffAgainstBaseline returns the rows of data that are new or differ from their baseline row (matched by
+// slug), with ChangeType and ChangedFields set. Only columns present in both are compared, so a baseline
+// written with other options doesn't make every row look changed. The number of unchanged rows is returned too
+func diffAgainstBaseline(data []PluginMeta, baseline map[string]map[string]string) ([]PluginMeta, int) {
+	columns := baselineColumns()
+	var changed []PluginMeta
+	unchanged := 0
+	for _, meta := range data {
+		previous, ok := baseline[pluginKey(meta)]
+		if !ok {
+			meta.ChangeType = changeAdded
+			changed = append(changed, meta)
+			continue
+		}
+		current := rowValues(columns, pluginRow(meta))
+		var fields []string
+		for _, name := range columns {
+			value, ok := previous[name]
+			if ok && value != current[name] && !slices.Contains(baselineIgnoredColumns, name) {
+				fields = append(fields, name)
+			}
+		}
+		if len(fields) == 0 {
+			unchanged++
+			continue
+		}
+		meta.ChangeType, meta.ChangedFields = changeChanged, fields
+		changed = append(changed, meta)
+	}
+	return changed, unchanged
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// breakerState is the state of a per-host circuit breaker
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// hostBreaker tracks consecutive failures against a single host
+type hostBreaker struct {
+	state     breakerState
+	failures  int
+	openUntil time.Time
+}
+
+// breakerTransport is an http.RoundTripper implementing a per-host circuit breaker.
+// After threshold consecutive failures (network errors, 429 or 5xx responses) against a host,
+// the circuit opens and all requests to that host wait for cooldown. A single half-open trial
+// request then decides whether the circuit closes again or stays open for another cooldown
+type breakerTransport struct {
+	next      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*hostBreaker
+}
+
+// newBreakerTransport wraps next with a per-host circuit breaker
+func newBreakerTransport(next http.RoundTripper, threshold int, cooldown time.Duration) *breakerTransport {
+	return &breakerTransport{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  make(map[string]*hostBreaker),
+	}
+}
+
+// RoundTrip waits until the host's circuit allows a request, performs it and records the outcome
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Local files can't suffer an outage
+	if req.URL.Host == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	if err := t.wait(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	t.record(req.URL.Host, err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500)
+	return resp, err
+}
+
+// wait blocks while the host's circuit is open, or while another request is the half-open trial
+func (t *breakerTransport) wait(req *http.Request) error {
+	host := req.URL.Host
+	for {
+		t.mu.Lock()
+		b := t.breaker(host)
+		var wait time.Duration
+		switch b.state {
+		case breakerClosed:
+			t.mu.Unlock()
+			return nil
+		case breakerOpen:
+			if wait = time.Until(b.openUntil); wait <= 0 {
+				b.state = breakerHalfOpen
+				t.mu.Unlock()
+				log.Printf("Circuit half-open for %s, sending trial request", host)
+				return nil
+			}
+		case breakerHalfOpen:
+			wait = time.Second
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+	}
+}
+
+// record updates the host's circuit with the outcome of a request
+func (t *breakerTransport) record(host string, success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.breaker(host)
+	if success {
+		if b.state != breakerClosed {
+			log.Printf("Circuit closed for %s", host)
+		}
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= t.threshold) {
+		b.state = breakerOpen
+		b.openUntil = time.Now().Add(t.cooldown)
+		log.Printf("Circuit opened for %s after %d consecutive failures, pausing requests for %v", host, b.failures, t.cooldown)
+	}
+}
+
+// breaker returns the breaker for host, creating it on first use. t.mu must be held
+func (t *breakerTransport) breaker(host string) *hostBreaker {
+	b, ok := t.breakers[host]
+	if !ok {
+		b = &hostBreaker{}
+		t.breakers[host] = b
+	}
+	return b
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// browseCategories are the listings of the plugin directory that -browse accepts
+var browseCategories = []string{"popular", "featured", "new", "updated", "beta", "blocks"}
+
+// browsePageURL returns the URL of a page of a plugin directory listing: the browse category,
+// or the search results for term when it is set
+func browsePageURL(category, term string, page int) string {
+	path := "/plugins/browse/" + url.PathEscape(category) + "/"
+	if term != "" {
+		path = "/plugins/search/" + url.PathEscape(term) + "/"
+	}
+	if page > 1 {
+		path += fmt.Sprintf("page/%d/", page)
+	}
+	return "https://" + wordpressHost + path
+}
+
+// crawlPluginURLs collects plugin page URLs from up to maxPages pages of a browse category or
+// search listing, stopping early at the first page that doesn't exist or lists no plugins
+func crawlPluginURLs(category, term string, maxPages int, delayMin, delayMax time.Duration) ([]string, error) {
+	var urls []string
+	seen := make(map[string]bool)
+
+	for page := 1; page <= maxPages; page++ {
+		if page > 1 {
+			time.Sleep(randomDelay(delayMin, delayMax))
+		}
+
+		pageURL := browsePageURL(category, term, page)
+		found, err := fetchBrowsePage(pageURL)
+		if err != nil {
+			return urls, fmt.Errorf("failed to crawl %s: %w", pageURL, err)
+		}
+		if len(found) == 0 {
+			log.Printf("No more plugins listed at %s", pageURL)
+			break
+		}
+
+		for _, u := range found {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+		log.Printf("Crawled %s: %d plugins", pageURL, len(found))
+	}
+	return urls, nil
+}
+
+// fetchBrowsePage fetches a listing page and returns the plugin URLs on it. A 404 past the
+// last page of a listing yields no URLs rather than an error
+func fetchBrowsePage(pageURL string) ([]string, error) {
+	resp, err := httpClient.Get(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+	return parseBrowsePage(resp.Body, resp.Request.URL)
+}
+
+// parseBrowsePage extracts the plugin URLs from the cards of a listing page, resolving them against base
+func parseBrowsePage(r io.Reader, base *url.URL) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	doc.Find(".plugin-card .entry-title a[href], .wp-block-post-title a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		u, err := base.Parse(href)
+		if err != nil || pluginSlug(u.String()) == "" {
+			return
+		}
+		urls = append(urls, u.String())
+	})
+	return urls, nil
+}
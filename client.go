@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	// errRedirectLoop is returned when a redirect chain revisits a URL it has already been through
+	errRedirectLoop = errors.New("redirect loop detected")
+	// errTooManyRedirects is returned when a redirect chain exceeds -max-redirects
+	errTooManyRedirects = errors.New("too many redirects")
+	// errNotHTTPS is returned with -strict-security for requests that aren't made over https
+	errNotHTTPS = errors.New("not an https URL")
+	// errRedirectScheme is returned when a page redirects to a scheme other than http or https, e.g. file://
+	errRedirectScheme = errors.New("redirect to an unsupported scheme")
+	// errRedirectHost is returned when a page redirects to a host that isn't allowed (-allow-host)
+	errRedirectHost = errors.New("redirect to a host that is not allowed")
+)
+
+// httpClient is the client used for all scraping requests; main replaces it with one built from the Config
+var httpClient = http.DefaultClient
+
+// tlsVersions maps the accepted -tls-min-version values to their crypto/tls constants
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// strictSecurityTLSVersion is the minimum TLS version of -strict-security, and its default -tls-min-version
+const strictSecurityTLSVersion = "1.2"
+
+// newHTTPClient builds the HTTP client for a run from the transport-related options
+func newHTTPClient(cfg Config) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+	}
+	if cfg.TLSMinVersion != "" {
+		version, ok := tlsVersions[cfg.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %q", cfg.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// A custom TLS config disables HTTP/2 unless it is requested explicitly
+	transport.ForceAttemptHTTP2 = !cfg.ForceHTTP1
+	if cfg.ForceHTTP1 {
+		// A non-nil, empty TLSNextProto map disables HTTP/2 entirely
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if cfg.DNSCache > 0 {
+		// Same dialer settings as http.DefaultTransport
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.DialContext = newDNSCache(dialer, cfg.DNSCache).DialContext
+	}
+	// Serve file:// URLs from the local filesystem so saved pages go through the same extraction. Only
+	// -from-dir reads local files: otherwise any fetched page could point the client at one
+	if cfg.FromDir != "" {
+		transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	}
+
+	if cfg.HostConfigs != nil {
+		transport.Proxy = cfg.HostConfigs.proxy
+	}
+
+	var rt http.RoundTripper = transport
+	if cfg.Transport != nil {
+		rt = cfg.Transport
+	}
+	if cfg.BreakerThreshold > 0 {
+		rt = newBreakerTransport(rt, cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	if cfg.HostConfigs != nil {
+		rt = newHostConfigTransport(rt, cfg.HostConfigs)
+	}
+	if cfg.StrictSecurity {
+		rt = httpsOnlyTransport{next: rt}
+	}
+
+	client := &http.Client{
+		Transport:     rt,
+		CheckRedirect: checkRedirect(cfg.MaxRedirects, cfg.AllowHosts),
+	}
+	// Cookies are only kept when the site needs a session, e.g. a private plugin directory
+	if cfg.Cookie != "" || cfg.CookieFile != "" {
+		jar, err := newCookieJar(cfg.CookieFile)
+		if err != nil {
+			return nil, err
+		}
+		client.Jar = jar
+	}
+	return client, nil
+}
+
+// httpsOnlyTransport is an http.RoundTripper failing every request that isn't made over https (-strict-security).
+// Since redirects go through the transport too, a redirect to http fails rather than downgrading the connection
+type httpsOnlyTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip sends req if it is an https request
+func (t httpsOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %s (-strict-security)", errNotHTTPS, req.URL)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// checkRedirect returns a CheckRedirect policy that follows at most maxRedirects redirects, only to
+// http and https URLs on the hosts input URLs may have (see isAllowedURL), and stops immediately
+// when a redirect loops back to a URL already visited
+func checkRedirect(maxRedirects int, allowHosts []string) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("%w: %s", errRedirectScheme, req.URL)
+		}
+		if !isAllowedURL(req.URL.String(), allowHosts, false) {
+			return fmt.Errorf("%w: %s (see -allow-host)", errRedirectHost, req.URL)
+		}
+		for _, prev := range via {
+			if prev.URL.String() == req.URL.String() {
+				return fmt.Errorf("%w: %s", errRedirectLoop, req.URL)
+			}
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", errTooManyRedirects, maxRedirects)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// column is an exported output column backed by a (possibly nested) PluginMeta field
+type column struct {
+	Name  string
+	Index []int
+}
+
+// pluginColumns derives the output columns of a struct type in field order. A field's csv tag names
+// its column and "-" excludes it; untagged struct fields contribute their own fields' columns, and
+// other untagged fields are exported under their Go field name so no field is silently dropped
+func pluginColumns(t reflect.Type, parent []int) []column {
+	var columns []column
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		index := append(append([]int(nil), parent...), i)
+
+		name, tagged := field.Tag.Lookup("csv")
+		switch {
+		case name == "-":
+		case !tagged && field.Type.Kind() == reflect.Struct:
+			columns = append(columns, pluginColumns(field.Type, index)...)
+		case !tagged:
+			columns = append(columns, column{Name: field.Name, Index: index})
+		default:
+			columns = append(columns, column{Name: name, Index: index})
+		}
+	}
+	return columns
+}
+
+// columnNames returns the names of columns
+func columnNames(columns []column) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// withoutColumns returns columns without the ones named names
+func withoutColumns(columns []column, names ...string) []column {
+	var kept []column
+	for _, col := range columns {
+		if !slices.Contains(names, col.Name) {
+			kept = append(kept, col)
+		}
+	}
+	return kept
+}
+
+// formatCell renders a field value as a cell of the tabular outputs (CSV, XLSX, HTML), using its String
+// method when it has one. Typed fields therefore format themselves the same way in every output, as their
+// MarshalJSON methods do for JSON
+func formatCell(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if items, ok := v.Interface().([]string); ok {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprint(v.Interface())
+}
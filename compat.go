@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionPattern matches the first dotted version number in a string, e.g. "5.8" in "5.8 or higher"
+var versionPattern = regexp.MustCompile(`\d+(?:\.\d+)*`)
+
+// CompatRange is the WordPress version window a plugin declares support for,
+// built from its "Requires at least" (Min) and "Tested up to" (Max) versions
+type CompatRange struct {
+	Min string `csv:"WP Min Version" json:"min" desc:"Minimum required WordPress version, e.g. 5.8"`
+	Max string `csv:"WP Max Version" json:"max" desc:"Latest tested WordPress version, e.g. 6.6.2"`
+}
+
+// CompatibilityVotes are the "works"/"broken" votes the legacy compatibility widget of a plugin page
+// showed for the currently selected WordPress and plugin versions
+type CompatibilityVotes struct {
+	Works  int `json:"works" desc:"Number of users reporting that the plugin works"`
+	Broken int `json:"broken" desc:"Number of users reporting that the plugin is broken"`
+}
+
+// String returns the votes as "N works, M broken", or an empty string when the page had no votes
+func (v *CompatibilityVotes) String() string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d works, %d broken", v.Works, v.Broken)
+}
+
+// newCompatRange builds a CompatRange from the scraped WordPress version strings
+func newCompatRange(requires, testedUpTo string) CompatRange {
+	return CompatRange{
+		Min: normalizeVersion(requires),
+		Max: normalizeVersion(testedUpTo),
+	}
+}
+
+// IsCompatibleWith reports whether wpVersion lies within the range. Max is compared only to its own
+// precision, so a plugin tested up to 6.6 is considered compatible with 6.6.2. An empty bound is open
+func (r CompatRange) IsCompatibleWith(wpVersion string) bool {
+	v := normalizeVersion(wpVersion)
+	if v == "" {
+		return false
+	}
+	if r.Min != "" && compareVersions(v, r.Min) < 0 {
+		return false
+	}
+	if r.Max != "" {
+		parts := strings.Split(v, ".")
+		precision := len(strings.Split(r.Max, "."))
+		if len(parts) > precision {
+			v = strings.Join(parts[:precision], ".")
+		}
+		if compareVersions(v, r.Max) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeVersion extracts the dotted version number from a string such as "5.8 or higher"
+func normalizeVersion(s string) string {
+	return versionPattern.FindString(s)
+}
+
+// compareVersions compares two dotted version numbers numerically, treating missing parts as 0.
+// It returns -1, 0 or 1
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
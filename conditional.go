@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// conditionalEntry is what -conditional-cache remembers about a plugin page: its validators and the row scraped from it
+type conditionalEntry struct {
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"last_modified,omitempty"`
+	Meta         PluginMeta `json:"meta"`
+
+	// Options is the extraction options fingerprint of the run that scraped Meta (see scrapeOptions.fingerprint)
+	Options string `json:"options"`
+}
+
+// conditionalCache stores the ETag and Last-Modified validators of every fetched page, keyed by URL, so the
+// next run can send conditional requests and reuse the previous row when the page answers 304 Not Modified.
+// It is safe for concurrent use by the workers
+type conditionalCache struct {
+	filename string
+	// options is the extraction options fingerprint of this run; rows scraped with other options aren't reused
+	options string
+
+	mu      sync.Mutex
+	entries map[string]conditionalEntry
+	reused  int
+}
+
+// loadConditionalCache reads the cache file of a previous run; a missing file yields an empty cache.
+// options is the fingerprint of the extraction options of this run
+func loadConditionalCache(filename, options string) (*conditionalCache, error) {
+	c := &conditionalCache{filename: filename, options: options, entries: make(map[string]conditionalEntry)}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// prepare adds If-None-Match and If-Modified-Since headers to the request for url when its page has a
+// cached row scraped with the options of this run. A nil cache adds nothing
+func (c *conditionalCache) prepare(req *http.Request, url string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[url]
+	c.mu.Unlock()
+	// A row scraped with other options (e.g. without -faq) lacks fields this run extracts, so the
+	// page is fetched again
+	if !ok || entry.Options != c.options {
+		return
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+}
+
+// cached returns the row scraped from url by a previous run, for a 304 response
+func (c *conditionalCache) cached(url string) (PluginMeta, bool) {
+	if c == nil {
+		return PluginMeta{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[url]
+	if !ok || entry.Options != c.options {
+		return PluginMeta{}, false
+	}
+	c.reused++
+	return entry.Meta, true
+}
+
+// store remembers the validators of a freshly scraped page along with its row. Pages sent without
+// validators are forgotten, since they can't be revalidated
+func (c *conditionalCache) store(url string, header http.Header, meta PluginMeta) {
+	if c == nil {
+		return
+	}
+	entry := conditionalEntry{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified"), Meta: meta, Options: c.options}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.ETag == "" && entry.LastModified == "" {
+		delete(c.entries, url)
+		return
+	}
+	c.entries[url] = entry
+}
+
+// Reused returns how many pages were answered with 304 Not Modified and reused from the cache
+func (c *conditionalCache) Reused() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reused
+}
+
+// save writes the cache file. Entries of URLs not scraped in this run are kept
+func (c *conditionalCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeFileAtomic(c.filename, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(c.entries)
+	})
+}
@@ -0,0 +1,587 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// Config holds the command-line options for a scraping run
+type Config struct {
+	Input              string
+	InputGlob          string
+	InputFormat        string
+	InputField         string
+	Replay             string
+	StrictCSV          bool
+	MaxURLLength       int
+	OnlyFailed         bool
+	FromDir            string
+	Browse             string
+	Search             string
+	BrowsePages        int
+	PassthroughColumns []string
+	AllowHosts         []string
+	Renames            outputRenames
+
+	PrintSchema bool
+	ListFields  bool
+	Merge       string
+	MergeInputs []string
+	Format      string
+	Output      string
+	Compress    bool
+	Delimiter   rune
+	QuoteAll    bool
+	StatsOnly   bool
+	StatsFormat string
+	SplitSize   int
+	RunMetadata bool
+	JSONPerFile string
+	ShardDirs   int
+	Manifest    bool
+	Watch       time.Duration
+	Skip        int
+	Limit       int
+
+	Template       string
+	OutputTemplate *template.Template
+
+	GroupBy          string
+	GroupByFormat    string
+	GroupByDimension groupDimension
+
+	SampleEvery int
+
+	MaxFailures        int
+	MaxFailuresPercent float64
+	ShutdownGrace      time.Duration
+
+	RequireFields []string
+	RequireMode   string
+	RecordStatus  bool
+	StrictSlug    bool
+	Dedup         bool
+	Baseline      string
+	CIAnnotations bool
+
+	DefaultWarnThreshold float64
+
+	AdvancedStats  bool
+	ReleaseDates   bool
+	InstallsLog10  bool
+	DumpMetaItems  bool
+	DumpSelectors  string
+	FAQ            bool
+	Description    bool
+	DescriptionMax int
+	QuietHTTP      bool
+	AuditLog       string
+	ArchiveDir     string
+	ArchiveGzip    bool
+	Conditional    string
+	LatencyStats   bool
+	NameFromTitle  bool
+	NoDefaults     bool
+
+	NormalizeURL bool
+	EnforceHTTPS bool
+	Locale       string
+
+	TUI   bool
+	Pprof string
+
+	Workers     int
+	WorkersAuto bool
+	MaxWorkers  int
+	MaxPerHost  int
+
+	ErrorRateThreshold float64
+	ErrorRateRecover   float64
+	ErrorRateWindow    int
+
+	DelayMin time.Duration
+	DelayMax time.Duration
+
+	BreakerThreshold  int
+	BreakerCooldown   time.Duration
+	Retries           int
+	RetryAfterMax     time.Duration
+	RetryOnParseError bool
+	RequeueThrottled  bool
+
+	Cookie                string
+	CookieFile            string
+	UserAgentFile         string
+	UserAgents            []string
+	HostConfigFile        string
+	HostConfigs           hostConfigs
+	ForceHTTP1            bool
+	LogConnections        bool
+	MaxRedirects          int
+	TLSMinVersion         string
+	TLSInsecureSkipVerify bool
+	DNSCache              time.Duration
+
+	// StrictSecurity restricts every request to https with verified certificates and TLS 1.2 or later
+	StrictSecurity bool
+
+	// JSONProvenance wraps every JSON value with the source of the field
+	JSONProvenance bool
+
+	// Transport, if set, replaces the network transport beneath the client's own layers (circuit breaker,
+	// -host-config, -strict-security, redirect policy). It has no flag: tests set it to serve canned
+	// responses, and the TLS, DNS cache, HTTP/2 and file:// settings don't apply to it
+	Transport http.RoundTripper
+}
+
+// parseFlags parses the command-line flags into a Config
+func parseFlags() (Config, error) {
+	cfg := Config{
+		Delimiter: ',',
+		Workers:   1,
+		DelayMin:  1 * time.Second,
+		DelayMax:  5 * time.Second,
+	}
+
+	flag.StringVar(&cfg.Input, "input", "plugin_urls.csv", "file listing the plugins to scrape, as CSV (URL in the first column) or a JSON array of objects")
+	flag.StringVar(&cfg.InputGlob, "input-glob", "", "read the plugins of every file matching this pattern instead of -input, e.g. \"lists/*.csv\", skipping plugins listed in more than one file and recording each plugin's file in the Source File column")
+	flag.StringVar(&cfg.InputFormat, "input-format", "auto", "format of the -input file: auto (by extension: .json or .csv), csv or json")
+	flag.StringVar(&cfg.InputField, "input-field", "slug", "dotted path of the field holding the plugin URL or slug in each object of a JSON input, e.g. plugin.slug")
+	flag.IntVar(&cfg.MaxURLLength, "max-url-length", 2048, "skip input rows whose URL is longer than this many characters, logging the line (0 means no limit)")
+	flag.BoolVar(&cfg.StrictCSV, "strict-csv", false, "reject a CSV -input whose rows don't all have as many fields as the header, reporting the offending line, instead of reading the URL column of ragged rows")
+	flag.StringVar(&cfg.Replay, "replay", "", "export the rows of a previous -format json output, an NDJSON file or a -json-per-file directory in the chosen output format, without scraping anything")
+	flag.BoolVar(&cfg.OnlyFailed, "only-failed", false, "re-scrape only the URLs in the errors report of a previous run and merge successes into the existing CSV output")
+	flag.StringVar(&cfg.FromDir, "from-dir", "", "scrape saved .html plugin pages from this directory instead of fetching plugin_urls.csv (the file name is the slug)")
+	flag.StringVar(&cfg.Browse, "browse", "", "crawl this plugin directory listing for plugin URLs instead of reading plugin_urls.csv: "+strings.Join(browseCategories, ", "))
+	flag.StringVar(&cfg.Search, "search", "", "crawl the plugin directory search results for this term instead of reading plugin_urls.csv")
+	flag.IntVar(&cfg.BrowsePages, "browse-pages", 5, "maximum number of listing pages to crawl with -browse or -search")
+	flag.Func("passthrough-columns", "comma-separated input CSV columns to copy into each output row, e.g. id,category,owner", func(s string) error {
+		cfg.PassthroughColumns = splitList(s)
+		return nil
+	})
+	var rename []string
+	flag.Func("rename", "comma-separated source=target pairs renaming output columns and JSON keys, e.g. \"Version=plugin_version,Active Installations=active_installs\"", func(s string) error {
+		rename = splitList(s)
+		return nil
+	})
+	flag.Func("allow-host", "comma-separated extra hosts (and their subdomains) URLs may be fetched from besides wordpress.org, e.g. a mirror; * allows any host", func(s string) error {
+		cfg.AllowHosts = append(cfg.AllowHosts, splitList(s)...)
+		return nil
+	})
+	flag.BoolVar(&cfg.PrintSchema, "print-schema", false, "print a JSON Schema describing the -format json output and exit")
+	flag.BoolVar(&cfg.ListFields, "list-fields", false, "print the PluginMeta field names accepted by -require-fields, -group-by, -rename and -template, with their type, default and description, and exit")
+	flag.StringVar(&cfg.Merge, "merge", "", "merge the result CSVs given as arguments into this file, keeping the most recently fetched row per plugin, and exit")
+	flag.StringVar(&cfg.Format, "format", "csv", "output format: csv, json, xlsx, parquet or html")
+	flag.StringVar(&cfg.Output, "output", "", "write the results to this file, or to an object store URL such as s3://bucket/key.csv or gs://bucket/key.csv in builds with -tags s3 or -tags gcs (default plugin_meta_results.<format>)")
+	flag.StringVar(&cfg.JSONPerFile, "json-per-file", "", "write each plugin to its own {slug}.json file in this directory (or object store prefix) instead of writing -format")
+	flag.BoolVar(&cfg.JSONProvenance, "json-provenance", false, "write every field of the JSON output as {\"value\": ..., \"source\": ...}, the source being scrape, api, input, default or title-fallback (not readable by -replay or -baseline)")
+	flag.IntVar(&cfg.ShardDirs, "shard-dirs", 0, "with -json-per-file, spread the files over this many levels of subdirectories named after the first characters of the slug, e.g. a/akismet.json for 1 (0 writes all files to the directory itself)")
+	flag.StringVar(&cfg.Template, "template", "", "render each plugin with this Go text/template file instead of writing -format, e.g. {{.Name}} {{.Version}}; the output goes to -output, or to stdout when -output is not set")
+	flag.BoolVar(&cfg.StatsOnly, "stats-only", false, "print aggregates (plugins by install tier and tested-up-to version, share updated in the last year) instead of writing the row-level output")
+	flag.StringVar(&cfg.StatsFormat, "stats-format", "table", "format of the -stats-only aggregates: table or json")
+	flag.StringVar(&cfg.GroupBy, "group-by", "", "also write plugin counts grouped by tested-up-to, wp-version, php-version, install-tier or any output field, e.g. Languages")
+	flag.StringVar(&cfg.GroupByFormat, "group-by-format", "csv", "format of the -group-by counts: csv or json")
+	flag.Func("delimiter", "field delimiter of the CSV output, a single character such as ; or \\t for tab (default ,)", func(s string) error {
+		if s == `\t` {
+			s = "\t"
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || size != len(s) || r == utf8.RuneError {
+			return fmt.Errorf("must be a single character")
+		}
+		if r == '"' || r == '\r' || r == '\n' {
+			return fmt.Errorf("cannot be a quote or line break")
+		}
+		cfg.Delimiter = r
+		return nil
+	})
+	flag.BoolVar(&cfg.QuoteAll, "quote-all", false, "quote every field of the CSV output, not just those that need it")
+	flag.BoolVar(&cfg.Compress, "compress", false, "gzip the output file and add a .gz extension, e.g. plugin_meta_results.csv.gz")
+	flag.DurationVar(&cfg.Watch, "watch", 0, "re-scrape the input every interval (e.g. 6h) until interrupted, writing a snapshot file named after each cycle's start time")
+	flag.DurationVar(&cfg.ShutdownGrace, "shutdown-grace", 30*time.Second, "on interrupt, stop dispatching URLs and wait up to this long for the URLs in flight to finish before exporting")
+	flag.BoolVar(&cfg.RunMetadata, "run-metadata", false, "write the resolved options, scraper version and run timestamps to a .run.json file next to the output")
+	flag.BoolVar(&cfg.Manifest, "manifest", false, "write a .manifest.json next to the output listing the SHA-256, size and row count of every file written")
+	flag.IntVar(&cfg.SplitSize, "split-size", 0, "rotate the output into numbered files every N rows (0 writes a single file)")
+	flag.IntVar(&cfg.Skip, "skip", 0, "discard the first N URLs of the input before processing")
+	flag.IntVar(&cfg.Skip, "continue-from", 0, "alias for -skip")
+	flag.IntVar(&cfg.SampleEvery, "sample-every", 1, "process only every Kth URL (after -skip, before -limit)")
+	flag.IntVar(&cfg.Limit, "limit", 0, "process at most N URLs (0 means no limit)")
+	flag.Func("max-failures", "abort the run once this many URLs have failed, as a count (50) or a percentage of the URLs to process (20%); partial results are still exported", func(s string) error {
+		if pct, ok := strings.CutSuffix(s, "%"); ok {
+			v, err := strconv.ParseFloat(pct, 64)
+			if err != nil || v <= 0 || v > 100 {
+				return fmt.Errorf("percentage must be between 0 and 100")
+			}
+			cfg.MaxFailures, cfg.MaxFailuresPercent = 0, v
+			return nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return fmt.Errorf("must be a non-negative count or a percentage")
+		}
+		cfg.MaxFailures, cfg.MaxFailuresPercent = n, 0
+		return nil
+	})
+	flag.Func("require-fields", "comma-separated fields that must be scraped (not empty or default), e.g. Name,Version", func(s string) error {
+		var err error
+		cfg.RequireFields, err = resolvePluginFields(splitList(s))
+		return err
+	})
+	flag.StringVar(&cfg.Baseline, "baseline", "", "previous output (CSV or JSON) to compare against: only write the rows that are new or differ from it, with Change Type and Changed Fields columns")
+	flag.BoolVar(&cfg.Dedup, "dedup", false, "write one row per plugin slug, keeping the most complete (then most recently fetched) of duplicate rows")
+	flag.BoolVar(&cfg.CIAnnotations, "ci-annotations", false, "print failures and selector health warnings as GitHub Actions ::error::/::warning:: annotations on stdout")
+	flag.BoolVar(&cfg.RecordStatus, "record-status", false, "keep pages answering with a non-200 status as rows with their HTTP Status and default values, instead of reporting them as failures")
+	flag.BoolVar(&cfg.StrictSlug, "strict-slug", false, "treat a page whose slug after redirects differs from the requested slug (e.g. a renamed plugin or catch-all page) as an error instead of only recording it in Final Slug")
+	flag.StringVar(&cfg.RequireMode, "require-mode", "report", "what to do with rows missing a -require-fields field: report (move them to the errors report) or fail (keep them and exit non-zero)")
+	flag.Float64Var(&cfg.DefaultWarnThreshold, "default-warn-threshold", 0.5, "warn that a field's selector may be broken when more than this fraction of scraped rows lack the field (1 disables the check)")
+	flag.BoolVar(&cfg.AdvancedStats, "advanced-stats", false, "also fetch the \"Advanced View\" breakdown of active installs by plugin version (one extra request per plugin)")
+	flag.BoolVar(&cfg.ReleaseDates, "release-dates", false, "also fetch the first and latest release dates and the number of releases from the WordPress.org API (one extra request per plugin)")
+	flag.BoolVar(&cfg.InstallsLog10, "installs-log10", false, "add an Installs Log10 column with the base-10 logarithm of the active installations, for plotting adoption on a log scale")
+	flag.BoolVar(&cfg.QuietHTTP, "quiet-http", false, "keep scraper.log small: omit the per-URL progress and per-field default lines, keeping errors, warnings and summaries")
+	flag.BoolVar(&cfg.FAQ, "faq", false, "scrape the FAQ section (at most 20 question/answer pairs); JSON gets the pairs, CSV only their count")
+	flag.BoolVar(&cfg.Description, "description", false, "capture the full description as plain text (HTML stripped, whitespace normalized)")
+	flag.IntVar(&cfg.DescriptionMax, "description-max", 2000, "maximum length in characters of the -description text; longer text is truncated")
+	flag.BoolVar(&cfg.DumpMetaItems, "dump-meta-items", false, "log the raw text of every metadata <li> on each plugin page, for diagnosing selector problems")
+	flag.StringVar(&cfg.DumpSelectors, "dump-selectors", "", "fetch this plugin page, print for each field the selector used, whether it matched and the matched text, and exit")
+	flag.BoolVar(&cfg.NameFromTitle, "name-from-title", true, "fall back to the document <title> for the plugin name when h1.plugin-title is missing")
+	flag.BoolVar(&cfg.NoDefaults, "no-defaults", false, "leave fields that could not be scraped empty instead of filling in N/A, Unknown or 0.0.0")
+	flag.StringVar(&cfg.ArchiveDir, "archive-dir", "", "save the raw HTML of every fetched page to this directory as <slug>.html, for re-extraction later with -from-dir")
+	flag.StringVar(&cfg.Conditional, "conditional-cache", "", "remember the ETag and Last-Modified of every page in this file and send conditional requests on the next run, reusing the previous row for pages answering 304 Not Modified")
+	flag.BoolVar(&cfg.ArchiveGzip, "archive-gzip", false, "gzip-compress the pages saved with -archive-dir (<slug>.html.gz)")
+	flag.StringVar(&cfg.AuditLog, "audit-log", "", "write an NDJSON record of every scrape attempt (URL, attempt, status, error, duration, timestamp) to this file")
+	flag.BoolVar(&cfg.LatencyStats, "latency-stats", false, "report the min/median/p90/p99/max duration of the requests at the end of the run")
+	flag.BoolVar(&cfg.NormalizeURL, "normalize-url", true, "canonicalize URLs before fetching (https unless -enforce-https=false, trailing slash, lowercase host, locale subdomain stripped)")
+	flag.BoolVar(&cfg.EnforceHTTPS, "enforce-https", true, "upgrade http:// URLs to https when normalizing; disable for internal mirrors served over plain HTTP")
+	flag.StringVar(&cfg.Locale, "locale", "", "scrape a localized wordpress.org site, e.g. ja for ja.wordpress.org (requires -normalize-url)")
+	flag.BoolVar(&cfg.TUI, "tui", false, "show a live progress view with recent completions, throughput and error counts (only when stdout is a terminal)")
+	flag.StringVar(&cfg.Pprof, "pprof", "", "serve net/http/pprof profiles on this address while running, e.g. localhost:6060")
+	flag.Func("workers", "number of URLs to scrape concurrently, or auto to adapt concurrency to latency and errors (default 1)", func(s string) error {
+		if s == "auto" {
+			cfg.WorkersAuto = true
+			return nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return fmt.Errorf("must be a positive number or auto")
+		}
+		cfg.Workers, cfg.WorkersAuto = n, false
+		return nil
+	})
+	flag.IntVar(&cfg.MaxWorkers, "max-workers", 8, "upper bound on concurrency in -workers auto mode")
+	flag.IntVar(&cfg.MaxPerHost, "max-concurrent-per-host", 0, "maximum number of URLs of the same host scraped at once, so one slow host can't tie up all workers (0 means no limit besides -workers)")
+	flag.Func("delay-range", "random wait between URLs as MIN-MAX, e.g. 2-8s or 500ms-2s; a single value is a fixed delay and 0 disables it (default 1-5s)", func(s string) error {
+		var err error
+		cfg.DelayMin, cfg.DelayMax, err = parseDelayRange(s)
+		return err
+	})
+	flag.Float64Var(&cfg.ErrorRateThreshold, "throttle-on-error-rate", 0, "slow down when more than this fraction of the last -error-rate-window URLs failed (429, 5xx, network errors or pages without name and version), doubling the delay between URLs each time; 0 disables it")
+	flag.Float64Var(&cfg.ErrorRateRecover, "error-rate-recover", -1, "halve the -throttle-on-error-rate slowdown again once at most this fraction of the window failed (default half the threshold)")
+	flag.IntVar(&cfg.ErrorRateWindow, "error-rate-window", 20, "number of most recent URLs -throttle-on-error-rate computes the error rate over")
+	flag.IntVar(&cfg.BreakerThreshold, "breaker-threshold", 5, "open the per-host circuit breaker after N consecutive failures (0 disables it)")
+	flag.DurationVar(&cfg.BreakerCooldown, "breaker-cooldown", 2*time.Minute, "how long an open circuit pauses requests to a host before a trial request")
+	flag.IntVar(&cfg.Retries, "retries", 3, "how many times to retry a rate-limited (429) or unavailable (503) page; 0 disables retries")
+	flag.DurationVar(&cfg.RetryAfterMax, "retry-after-max", 5*time.Minute, "upper bound on the wait before retrying a 429 or 503 response, whether taken from its Retry-After header or from exponential backoff")
+	flag.BoolVar(&cfg.RequeueThrottled, "requeue-throttled", false, "put a rate-limited (429) or unavailable (503) page back in the queue to retry after its wait and carry on with other URLs meanwhile, instead of pausing all workers")
+	flag.BoolVar(&cfg.RetryOnParseError, "retry-on-parse-error", false, "also retry 200 responses parsed without the plugin name or version (possibly truncated pages), sharing the -retries budget")
+	flag.StringVar(&cfg.Cookie, "cookie", "", "cookies to send to the scraped hosts, as a Cookie header value, e.g. \"session=abc; token=xyz\"")
+	flag.StringVar(&cfg.CookieFile, "cookie-file", "", "load cookies from a Netscape cookies.txt file (as exported by browsers or curl)")
+	flag.StringVar(&cfg.UserAgentFile, "user-agent-file", "", "on a 403 Forbidden response (possibly bot detection), retry with each User-Agent listed in this file in turn, one per line; only use it where you are allowed to scrape")
+	flag.StringVar(&cfg.HostConfigFile, "host-config", "", "JSON file of per-host settings (rps, proxy, user_agent, headers) applied to the requests to each host and its subdomains")
+	flag.BoolVar(&cfg.ForceHTTP1, "force-http1", false, "disable HTTP/2 and always use HTTP/1.1")
+	flag.BoolVar(&cfg.LogConnections, "log-connections", false, "log the negotiated HTTP protocol and whether each request reused a connection")
+	flag.IntVar(&cfg.MaxRedirects, "max-redirects", 10, "maximum number of redirects to follow per request")
+	flag.DurationVar(&cfg.DNSCache, "dns-cache", 0, "cache resolved host addresses for this long instead of looking them up for every new connection, e.g. 5m (default 0: no caching, so DNS-based load balancing keeps working)")
+	flag.StringVar(&cfg.TLSMinVersion, "tls-min-version", "", "minimum TLS version to accept: 1.0, 1.1, 1.2 or 1.3 (default: Go's default)")
+	flag.BoolVar(&cfg.TLSInsecureSkipVerify, "tls-insecure-skip-verify", false, "skip TLS certificate verification (INSECURE: only for trusted intercepting proxies)")
+	flag.BoolVar(&cfg.StrictSecurity, "strict-security", false, "only ever send requests over https with verified certificates and TLS 1.2 or later: non-https input URLs are an error and http requests (including redirects) fail; conflicting options are rejected at startup")
+	flag.Parse()
+	cfg.MergeInputs = flag.Args()
+
+	if cfg.Merge != "" && len(cfg.MergeInputs) == 0 {
+		return cfg, fmt.Errorf("-merge requires the result CSVs to merge as arguments")
+	}
+	if _, ok := exporters[cfg.Format]; !ok {
+		return cfg, fmt.Errorf("unsupported -format %q (use csv, json, xlsx, parquet or html)", cfg.Format)
+	}
+	if !slices.Contains(inputFormats, cfg.InputFormat) {
+		return cfg, fmt.Errorf("unsupported -input-format %q (use %s)", cfg.InputFormat, strings.Join(inputFormats, ", "))
+	}
+	if cfg.StatsFormat != "table" && cfg.StatsFormat != "json" {
+		return cfg, fmt.Errorf("unsupported -stats-format %q (use table or json)", cfg.StatsFormat)
+	}
+	if cfg.DescriptionMax < 1 {
+		return cfg, fmt.Errorf("-description-max must be at least 1: %d", cfg.DescriptionMax)
+	}
+	if cfg.GroupByFormat != "csv" && cfg.GroupByFormat != "json" {
+		return cfg, fmt.Errorf("unsupported -group-by-format %q (use csv or json)", cfg.GroupByFormat)
+	}
+	if cfg.GroupBy != "" {
+		var err error
+		if cfg.GroupByDimension, err = resolveGroupBy(cfg.GroupBy); err != nil {
+			return cfg, fmt.Errorf("invalid -group-by: %v", err)
+		}
+	}
+	if cfg.Template != "" {
+		var err error
+		if cfg.OutputTemplate, err = loadOutputTemplate(cfg.Template); err != nil {
+			return cfg, fmt.Errorf("invalid -template: %v", err)
+		}
+		if cfg.OnlyFailed || cfg.StatsOnly || cfg.SplitSize > 0 {
+			return cfg, fmt.Errorf("-template cannot be combined with -only-failed, -stats-only or -split-size")
+		}
+		if cfg.Output == "" && (cfg.Compress || cfg.Manifest || cfg.Watch > 0 || cfg.CIAnnotations) {
+			return cfg, fmt.Errorf("-compress, -manifest, -watch and -ci-annotations require an -output file with -template")
+		}
+	}
+	if cfg.ShardDirs < 0 {
+		return cfg, fmt.Errorf("-shard-dirs must not be negative: %d", cfg.ShardDirs)
+	}
+	if cfg.ShardDirs > 0 && cfg.JSONPerFile == "" {
+		return cfg, fmt.Errorf("-shard-dirs requires -json-per-file")
+	}
+	if cfg.JSONProvenance && (cfg.Format != "json" || cfg.Template != "") && cfg.JSONPerFile == "" {
+		return cfg, fmt.Errorf("-json-provenance requires -format json or -json-per-file")
+	}
+	if cfg.Baseline != "" && (cfg.OnlyFailed || cfg.StatsOnly) {
+		return cfg, fmt.Errorf("-baseline cannot be combined with -only-failed or -stats-only")
+	}
+	if cfg.JSONPerFile != "" {
+		if err := checkOutputTarget(cfg.JSONPerFile); err != nil {
+			return cfg, fmt.Errorf("invalid -json-per-file: %v", err)
+		}
+		if cfg.Output != "" || cfg.Template != "" {
+			return cfg, fmt.Errorf("-json-per-file cannot be combined with -output or -template")
+		}
+		if cfg.OnlyFailed || cfg.StatsOnly || cfg.SplitSize > 0 {
+			return cfg, fmt.Errorf("-json-per-file cannot be combined with -only-failed, -stats-only or -split-size")
+		}
+		if _, remote := objectStoreScheme(cfg.JSONPerFile); remote && cfg.Manifest {
+			return cfg, fmt.Errorf("-manifest checksums the written files and requires a local -json-per-file directory")
+		}
+	}
+	if cfg.ShutdownGrace < 0 {
+		return cfg, fmt.Errorf("-shutdown-grace must not be negative: %v", cfg.ShutdownGrace)
+	}
+	if cfg.Watch < 0 {
+		return cfg, fmt.Errorf("-watch must not be negative: %v", cfg.Watch)
+	}
+	if cfg.Watch > 0 && cfg.OnlyFailed {
+		return cfg, fmt.Errorf("-watch cannot be combined with -only-failed")
+	}
+	if cfg.StatsOnly && cfg.OnlyFailed {
+		return cfg, fmt.Errorf("-stats-only cannot be combined with -only-failed")
+	}
+	if cfg.InputGlob != "" {
+		if _, err := filepath.Match(cfg.InputGlob, ""); err != nil {
+			return cfg, fmt.Errorf("invalid -input-glob %q: %v", cfg.InputGlob, err)
+		}
+		if cfg.Replay != "" || cfg.FromDir != "" || cfg.OnlyFailed || cfg.Browse != "" || cfg.Search != "" {
+			return cfg, fmt.Errorf("-input-glob cannot be combined with -replay, -from-dir, -only-failed, -browse or -search")
+		}
+	}
+	if cfg.Replay != "" && (cfg.FromDir != "" || cfg.OnlyFailed || cfg.Browse != "" || cfg.Search != "" || cfg.Watch > 0) {
+		return cfg, fmt.Errorf("-replay cannot be combined with -from-dir, -only-failed, -browse, -search or -watch")
+	}
+	if cfg.Replay != "" && (cfg.Skip > 0 || cfg.SampleEvery > 1 || cfg.Limit > 0) {
+		return cfg, fmt.Errorf("-replay exports every row and cannot be combined with -skip, -sample-every or -limit")
+	}
+	if cfg.Browse != "" && !slices.Contains(browseCategories, cfg.Browse) {
+		return cfg, fmt.Errorf("unsupported -browse %q (use %s)", cfg.Browse, strings.Join(browseCategories, ", "))
+	}
+	if cfg.Browse != "" && cfg.Search != "" {
+		return cfg, fmt.Errorf("-browse cannot be combined with -search")
+	}
+	if (cfg.Browse != "" || cfg.Search != "") && (cfg.FromDir != "" || cfg.OnlyFailed || len(cfg.PassthroughColumns) > 0) {
+		return cfg, fmt.Errorf("-browse and -search cannot be combined with -from-dir, -only-failed or -passthrough-columns")
+	}
+	if cfg.BrowsePages < 1 {
+		return cfg, fmt.Errorf("-browse-pages must be at least 1: %d", cfg.BrowsePages)
+	}
+	if cfg.FromDir != "" && cfg.OnlyFailed {
+		return cfg, fmt.Errorf("-from-dir cannot be combined with -only-failed")
+	}
+	if len(cfg.PassthroughColumns) > 0 && (cfg.FromDir != "" || cfg.OnlyFailed) {
+		return cfg, fmt.Errorf("-passthrough-columns requires the -input file and cannot be combined with -from-dir or -only-failed")
+	}
+	var err error
+	if cfg.Renames, err = resolveRenames(rename, cfg.PassthroughColumns); err != nil {
+		return cfg, fmt.Errorf("invalid -rename: %v", err)
+	}
+	if err := checkOutputTarget(cfg.Output); err != nil {
+		return cfg, fmt.Errorf("invalid -output: %v", err)
+	}
+	if err := checkOutputTarget(cfg.Merge); err != nil {
+		return cfg, fmt.Errorf("invalid -merge: %v", err)
+	}
+	if _, remote := objectStoreScheme(cfg.Output); remote && cfg.OnlyFailed {
+		return cfg, fmt.Errorf("-only-failed reads the existing results and requires a local -output")
+	}
+	if _, remote := objectStoreScheme(cfg.Output); remote && cfg.Manifest {
+		return cfg, fmt.Errorf("-manifest checksums the written files and requires a local -output")
+	}
+	if cfg.Manifest && cfg.StatsOnly {
+		return cfg, fmt.Errorf("-manifest cannot be combined with -stats-only")
+	}
+	if cfg.OnlyFailed && (cfg.Format != "csv" || cfg.SplitSize > 0 || cfg.Compress) {
+		return cfg, fmt.Errorf("-only-failed requires the single-file uncompressed CSV output (no other -format, -split-size or -compress)")
+	}
+	if cfg.SplitSize < 0 {
+		return cfg, fmt.Errorf("-split-size must not be negative: %d", cfg.SplitSize)
+	}
+	if cfg.Skip < 0 {
+		return cfg, fmt.Errorf("-skip must not be negative: %d", cfg.Skip)
+	}
+	if cfg.SampleEvery < 1 {
+		return cfg, fmt.Errorf("-sample-every must be at least 1: %d", cfg.SampleEvery)
+	}
+	if cfg.Limit < 0 {
+		return cfg, fmt.Errorf("-limit must not be negative: %d", cfg.Limit)
+	}
+	if cfg.RequireMode != "report" && cfg.RequireMode != "fail" {
+		return cfg, fmt.Errorf("unsupported -require-mode %q (use report or fail)", cfg.RequireMode)
+	}
+	if cfg.Pprof != "" {
+		if _, _, err := net.SplitHostPort(cfg.Pprof); err != nil {
+			return cfg, fmt.Errorf("invalid -pprof address %q: %v", cfg.Pprof, err)
+		}
+	}
+	if cfg.DefaultWarnThreshold < 0 || cfg.DefaultWarnThreshold > 1 {
+		return cfg, fmt.Errorf("-default-warn-threshold must be between 0 and 1: %v", cfg.DefaultWarnThreshold)
+	}
+	if cfg.MaxWorkers < 1 {
+		return cfg, fmt.Errorf("-max-workers must be at least 1: %d", cfg.MaxWorkers)
+	}
+	if cfg.MaxURLLength < 0 {
+		return cfg, fmt.Errorf("-max-url-length must not be negative: %d", cfg.MaxURLLength)
+	}
+	if cfg.MaxPerHost < 0 {
+		return cfg, fmt.Errorf("-max-concurrent-per-host must not be negative: %d", cfg.MaxPerHost)
+	}
+	if cfg.ErrorRateThreshold < 0 || cfg.ErrorRateThreshold > 1 {
+		return cfg, fmt.Errorf("-throttle-on-error-rate must be between 0 and 1: %v", cfg.ErrorRateThreshold)
+	}
+	if cfg.ErrorRateRecover < 0 {
+		cfg.ErrorRateRecover = cfg.ErrorRateThreshold / 2
+	}
+	if cfg.ErrorRateRecover >= cfg.ErrorRateThreshold && cfg.ErrorRateThreshold > 0 {
+		return cfg, fmt.Errorf("-error-rate-recover must be below -throttle-on-error-rate: %v", cfg.ErrorRateRecover)
+	}
+	if cfg.ErrorRateWindow < 1 {
+		return cfg, fmt.Errorf("-error-rate-window must be positive: %d", cfg.ErrorRateWindow)
+	}
+	if cfg.BreakerThreshold < 0 {
+		return cfg, fmt.Errorf("-breaker-threshold must not be negative: %d", cfg.BreakerThreshold)
+	}
+	if cfg.BreakerCooldown < 0 {
+		return cfg, fmt.Errorf("-breaker-cooldown must not be negative: %v", cfg.BreakerCooldown)
+	}
+	if cfg.Retries < 0 {
+		return cfg, fmt.Errorf("-retries must not be negative: %d", cfg.Retries)
+	}
+	if cfg.RetryAfterMax <= 0 {
+		return cfg, fmt.Errorf("-retry-after-max must be positive: %v", cfg.RetryAfterMax)
+	}
+	if cfg.Cookie != "" {
+		if _, err := http.ParseCookie(cfg.Cookie); err != nil {
+			return cfg, fmt.Errorf("invalid -cookie value: %v", err)
+		}
+	}
+	if cfg.UserAgentFile != "" {
+		var err error
+		if cfg.UserAgents, err = readUserAgents(cfg.UserAgentFile); err != nil {
+			return cfg, fmt.Errorf("invalid -user-agent-file: %v", err)
+		}
+	}
+	if cfg.HostConfigFile != "" {
+		var err error
+		if cfg.HostConfigs, err = loadHostConfigs(cfg.HostConfigFile); err != nil {
+			return cfg, fmt.Errorf("invalid -host-config: %v", err)
+		}
+	}
+	if cfg.MaxRedirects < 0 {
+		return cfg, fmt.Errorf("-max-redirects must not be negative: %d", cfg.MaxRedirects)
+	}
+	if cfg.DNSCache < 0 {
+		return cfg, fmt.Errorf("-dns-cache must not be negative: %v", cfg.DNSCache)
+	}
+	if cfg.Locale != "" && !cfg.NormalizeURL {
+		return cfg, fmt.Errorf("-locale requires -normalize-url")
+	}
+	if _, ok := tlsVersions[cfg.TLSMinVersion]; cfg.TLSMinVersion != "" && !ok {
+		return cfg, fmt.Errorf("unsupported -tls-min-version %q (use 1.0, 1.1, 1.2 or 1.3)", cfg.TLSMinVersion)
+	}
+	if cfg.StrictSecurity {
+		switch {
+		case cfg.TLSInsecureSkipVerify:
+			return cfg, fmt.Errorf("-strict-security cannot be combined with -tls-insecure-skip-verify")
+		case !cfg.EnforceHTTPS:
+			return cfg, fmt.Errorf("-strict-security cannot be combined with -enforce-https=false")
+		case cfg.FromDir != "":
+			return cfg, fmt.Errorf("-strict-security only fetches https URLs and cannot be combined with -from-dir")
+		case cfg.TLSMinVersion != "" && tlsVersions[cfg.TLSMinVersion] < tlsVersions[strictSecurityTLSVersion]:
+			return cfg, fmt.Errorf("-strict-security requires -tls-min-version %s or later, not %s", strictSecurityTLSVersion, cfg.TLSMinVersion)
+		}
+		if cfg.TLSMinVersion == "" {
+			cfg.TLSMinVersion = strictSecurityTLSVersion
+		}
+	}
+
+	return cfg, nil
+}
+
+// failureLimit returns the number of failures that aborts a run of total URLs (0 means never abort)
+func (cfg Config) failureLimit(total int) int {
+	if cfg.MaxFailuresPercent > 0 {
+		return max(1, int(math.Ceil(cfg.MaxFailuresPercent/100*float64(total))))
+	}
+	return cfg.MaxFailures
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDelayRange parses a delay range such as "2-8s", "500ms-2s", "3s" or "0".
+// A unit given only on the upper bound ("2-8s") applies to both bounds
+func parseDelayRange(s string) (time.Duration, time.Duration, error) {
+	lo, hi, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	if !isRange {
+		hi = lo
+	}
+	if unit := strings.TrimLeft(hi, "0123456789."); unit != "" && strings.TrimLeft(lo, "0123456789.") == "" {
+		lo += unit
+	}
+
+	minDelay, err := time.ParseDuration(lo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid delay range %q: %v", s, err)
+	}
+	maxDelay, err := time.ParseDuration(hi)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid delay range %q: %v", s, err)
+	}
+	if minDelay < 0 {
+		return 0, 0, fmt.Errorf("invalid delay range %q: delays must not be negative", s)
+	}
+	if maxDelay < minDelay {
+		return 0, 0, fmt.Errorf("invalid delay range %q: max must be >= min", s)
+	}
+	return minDelay, maxDelay, nil
+}
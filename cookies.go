@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// newCookieJar creates a cookie jar, preloaded from a Netscape cookies.txt file when filename is set
+func newCookieJar(filename string) (http.CookieJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return nil, err
+	}
+	if filename != "" {
+		if err := loadCookieFile(jar, filename); err != nil {
+			return nil, fmt.Errorf("failed to load cookie file: %w", err)
+		}
+	}
+	return jar, nil
+}
+
+// loadCookieFile reads cookies in the Netscape cookies.txt format exported by browsers and curl:
+// tab-separated domain, include-subdomains flag, path, secure flag, expiry, name and value
+func loadCookieFile(jar http.CookieJar, filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		// Only the line ending is trimmed: a cookie with an empty value ends in a tab
+		text := strings.TrimRight(scanner.Text(), "\r")
+		// curl marks HttpOnly cookies with a "#HttpOnly_" prefix on the domain
+		text = strings.TrimPrefix(text, "#HttpOnly_")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(text, "\t", 7)
+		if len(fields) != 7 {
+			return fmt.Errorf("%s:%d: expected 7 tab-separated fields, got %d", filename, line, len(fields))
+		}
+		domain, path, secure, name, value := fields[0], fields[2], fields[3] == "TRUE", fields[5], fields[6]
+
+		cookie := &http.Cookie{Name: name, Value: value, Path: path, Secure: secure}
+		if fields[1] == "TRUE" {
+			cookie.Domain = domain
+		}
+		if expiry, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		jar.SetCookies(&url.URL{Scheme: scheme, Host: strings.TrimPrefix(domain, "."), Path: path}, []*http.Cookie{cookie})
+	}
+	return scanner.Err()
+}
+
+// addCookieHeader adds the cookies of a Cookie header value such as "session=abc; token=xyz"
+// to the jar for the hosts of urls, so they are only sent to the sites being scraped
+func addCookieHeader(jar http.CookieJar, header string, urls []string) error {
+	cookies, err := http.ParseCookie(header)
+	if err != nil {
+		return fmt.Errorf("invalid -cookie value: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || seen[u.Host] {
+			continue
+		}
+		seen[u.Host] = true
+		jar.SetCookies(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/"}, cookies)
+	}
+	return nil
+}
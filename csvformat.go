@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// csvDelimiter and csvQuoteAll configure the results CSV, set from -delimiter and -quote-all
+var (
+	csvDelimiter = ','
+	csvQuoteAll  bool
+)
+
+// csvRecordWriter is the part of *csv.Writer used to write the results CSV
+type csvRecordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// newResultsCSVWriter returns a writer for the results CSV that honours -delimiter and -quote-all
+func newResultsCSVWriter(w io.Writer) csvRecordWriter {
+	if csvQuoteAll {
+		return &quoteAllWriter{w: bufio.NewWriter(w), comma: csvDelimiter}
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = csvDelimiter
+	return writer
+}
+
+// newResultsCSVReader returns a reader for a results CSV written with -delimiter
+func newResultsCSVReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = csvDelimiter
+	return reader
+}
+
+// quoteAllWriter writes CSV records with every field quoted, for importers that require it.
+// encoding/csv only quotes fields that need it
+type quoteAllWriter struct {
+	w     *bufio.Writer
+	comma rune
+	err   error
+}
+
+// Write writes a record with every field quoted
+func (q *quoteAllWriter) Write(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			q.w.WriteRune(q.comma)
+		}
+		q.w.WriteByte('"')
+		q.w.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		q.w.WriteByte('"')
+	}
+	// bufio.Writer errors are sticky, so the last write reports any earlier failure
+	_, q.err = q.w.WriteString("\n")
+	return q.err
+}
+
+// Flush writes any buffered data to the underlying writer
+func (q *quoteAllWriter) Flush() {
+	if q.err == nil {
+		q.err = q.w.Flush()
+	}
+}
+
+// Error reports any error from a previous Write or Flush
+func (q *quoteAllWriter) Error() error {
+	return q.err
+}
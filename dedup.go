@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+)
+
+// defaultedFields are the PluginMeta fields with a default tag, whose default marks a value that couldn't be scraped
+var defaultedFields = func() []string {
+	var fields []string
+	t := reflect.TypeOf(PluginMeta{})
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("default"); ok {
+			fields = append(fields, t.Field(i).Name)
+		}
+	}
+	return fields
+}()
+
+// dedupPlugins keeps one row per plugin slug, at the position of its first occurrence. Of duplicate
+// rows the one with the fewest missing or defaulted fields wins, then the one fetched most recently
+func dedupPlugins(data []PluginMeta) []PluginMeta {
+	index := make(map[string]int)
+	deduped := make([]PluginMeta, 0, len(data))
+	for _, meta := range data {
+		key := pluginKey(meta)
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(deduped)
+			deduped = append(deduped, meta)
+		} else if betterRow(meta, deduped[i]) {
+			deduped[i] = meta
+		}
+	}
+	return deduped
+}
+
+// pluginKey returns the plugin slug identifying a row, falling back to the key of its URL (see urlKey)
+func pluginKey(meta PluginMeta) string {
+	if meta.Slug != "" && meta.Slug != "N/A" {
+		return meta.Slug
+	}
+	return urlKey(meta.URL)
+}
+
+// betterRow reports whether row a holds more complete data than row b, or is equally complete
+// and was fetched more recently
+func betterRow(a, b PluginMeta) bool {
+	missingA := len(missingRequiredFields(a, defaultedFields))
+	missingB := len(missingRequiredFields(b, defaultedFields))
+	if missingA != missingB {
+		return missingA < missingB
+	}
+	return a.FetchedAt.After(b.FetchedAt.Time)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// errorRateMaxSlowdown caps how many times the normal delay the error rate controller may wait between URLs
+const errorRateMaxSlowdown = 32
+
+// errorRateMinDelay is the delay slowed down when the -delay-range is 0, so the controller still has an effect
+const errorRateMinDelay = time.Second
+
+// errorRateController slows the whole run down when the share of failed URLs in a sliding window of the
+// most recent results climbs above threshold, and speeds it up again once the share drops to recover.
+// This catches soft blocking that doesn't show as clean 429s, such as random timeouts or truncated pages.
+// Each step doubles or halves the slowdown, and the window starts over after every step so the next
+// decision is based on results scraped at the new rate
+type errorRateController struct {
+	mu        sync.Mutex
+	threshold float64
+	recover   float64
+	// window holds whether each of the last len(window) results failed, oldest first
+	window   []bool
+	size     int
+	failures int
+	slowdown int
+}
+
+// newErrorRateController returns a controller over a window of size results, or nil when threshold is 0
+func newErrorRateController(threshold, recover float64, size int) *errorRateController {
+	if threshold <= 0 {
+		return nil
+	}
+	return &errorRateController{threshold: threshold, recover: recover, size: size, slowdown: 1}
+}
+
+// isSoftFailure reports whether r counts as a failure for the error rate: an overload error, or a page
+// parsed without the critical fields, which is often a truncated response
+func isSoftFailure(r urlResult) bool {
+	return r.Overloaded || (r.Err == nil && len(missingRequiredFields(r.Meta, criticalFields)) > 0)
+}
+
+// record adds the outcome of a URL to the window and adjusts the slowdown once the window is full.
+// A nil controller ignores it
+func (c *errorRateController) record(failed bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.window = append(c.window, failed)
+	if failed {
+		c.failures++
+	}
+	if len(c.window) > c.size {
+		if c.window[0] {
+			c.failures--
+		}
+		c.window = c.window[1:]
+	}
+	if len(c.window) < c.size {
+		return
+	}
+
+	rate := float64(c.failures) / float64(c.size)
+	previous := c.slowdown
+	switch {
+	case rate > c.threshold && c.slowdown < errorRateMaxSlowdown:
+		c.slowdown *= 2
+	case rate <= c.recover && c.slowdown > 1:
+		c.slowdown /= 2
+	default:
+		return
+	}
+	log.Printf("Error rate %.0f%% over the last %d URLs: delay between URLs x%d -> x%d", rate*100, c.size, previous, c.slowdown)
+	c.window, c.failures = c.window[:0], 0
+}
+
+// delay returns the wait before the next URL given the normal delay d. A nil controller returns d
+func (c *errorRateController) delay(d time.Duration) time.Duration {
+	if c == nil {
+		return d
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.slowdown == 1 {
+		return d
+	}
+	return max(d, errorRateMinDelay) * time.Duration(c.slowdown)
+}
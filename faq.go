@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Caps on the FAQ captured with -faq, since some readmes carry dozens of long entries
+const (
+	maxFAQItems        = 20
+	maxFAQAnswerLength = 1000
+)
+
+// FAQItem is a question/answer pair of the plugin readme's FAQ section
+type FAQItem struct {
+	Question string `json:"question" parquet:"question" desc:"The question"`
+	Answer   string `json:"answer" parquet:"answer" desc:"The answer as plain text, truncated to 1000 characters"`
+}
+
+// FAQ is the FAQ section of a plugin page. Only its item count is exported to CSV
+type FAQ []FAQItem
+
+// String returns the number of FAQ items, or an empty string when the FAQ wasn't scraped
+func (f FAQ) String() string {
+	if f == nil {
+		return ""
+	}
+	return strconv.Itoa(len(f))
+}
+
+// extractFAQ extracts the question/answer pairs of the FAQ section, at most maxFAQItems of them.
+// It returns an empty, non-nil FAQ when the page has none
+func extractFAQ(doc *goquery.Document) FAQ {
+	faq := FAQ{}
+	doc.Find("#faq dt, #tab-faq dt, .plugin-faq dt").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		question := strings.Join(strings.Fields(s.Text()), " ")
+		if question == "" {
+			return true
+		}
+		answer := strings.Join(strings.Fields(s.NextFiltered("dd").Text()), " ")
+		if runes := []rune(answer); len(runes) > maxFAQAnswerLength {
+			answer = string(runes[:maxFAQAnswerLength-1]) + "…"
+		}
+		faq = append(faq, FAQItem{Question: question, Answer: answer})
+		return len(faq) < maxFAQItems
+	})
+	return faq
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// freemiumLinkPattern matches the text of links that sell a paid upgrade, e.g. "Upgrade to Pro" or "Get Premium"
+var freemiumLinkPattern = regexp.MustCompile(`(?i)\b(?:upgrade to|get|buy|go) (?:the )?(?:pro|premium)\b`)
+
+// freemiumTextPattern matches description phrases that advertise a paid edition of the plugin,
+// e.g. "the Pro version" or "premium add-ons". A lone "pro" or "premium" is not enough, since
+// it is common in unrelated descriptions ("pro tips", "premium themes")
+var freemiumTextPattern = regexp.MustCompile(`(?i)\b(?:pro|premium) (?:version|edition|add-?ons?|plan)s?\b|\bupgrade to (?:pro|premium)\b`)
+
+// freemiumBadgeSelector locates premium or commercial badges in the plugin header
+const freemiumBadgeSelector = `.plugin-header [class*="premium"], .plugin-header [class*="commercial"], .entry-meta [class*="premium"], .entry-meta [class*="commercial"]`
+
+// descriptionSelector locates the description section of a plugin page
+const descriptionSelector = `#tab-description, .plugin-description`
+
+// detectFreemium reports whether the plugin page advertises a paid pro/premium version, along with
+// the evidence for it. The signals are checked in order of reliability: a premium or commercial badge
+// in the plugin header, a link in the description selling an upgrade, then an explicit mention of a
+// paid edition in the description text. The evidence is the first signal found
+func detectFreemium(doc *goquery.Document) (bool, string) {
+	if badge := doc.Find(freemiumBadgeSelector).First(); badge.Length() > 0 {
+		class, _ := badge.Attr("class")
+		return true, fmt.Sprintf("badge: %s", strings.TrimSpace(class))
+	}
+
+	description := doc.Find(descriptionSelector)
+	var evidence string
+	description.Find("a").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if freemiumLinkPattern.MatchString(s.Text()) {
+			evidence = fmt.Sprintf("link: %s", strings.Join(strings.Fields(s.Text()), " "))
+		}
+		return evidence == ""
+	})
+	if evidence != "" {
+		return true, evidence
+	}
+
+	if m := freemiumTextPattern.FindString(strings.Join(strings.Fields(description.Text()), " ")); m != "" {
+		return true, fmt.Sprintf("description: %s", m)
+	}
+	return false, ""
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostSettings are the -host-config settings of one host
+type hostSettings struct {
+	// RPS caps the requests per second sent to the host; 0 means no cap
+	RPS float64 `json:"rps"`
+	// Proxy is the URL of the proxy requests to the host go through, overriding HTTP_PROXY/HTTPS_PROXY
+	Proxy string `json:"proxy"`
+	// UserAgent replaces the default User-Agent
+	UserAgent string `json:"user_agent"`
+	// Headers are added to every request to the host
+	Headers map[string]string `json:"headers"`
+
+	proxyURL *url.URL
+}
+
+// hostConfigs maps host names to their settings. An entry also applies to the subdomains of its host
+type hostConfigs map[string]*hostSettings
+
+// loadHostConfigs reads a -host-config file: a JSON object mapping host names to their settings, e.g.
+// {"wordpress.org": {"rps": 1}, "mirror.example.com": {"proxy": "http://proxy:3128", "headers": {"X-Token": "..."}}}
+func loadHostConfigs(filename string) (hostConfigs, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]*hostSettings
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// A misspelt setting would otherwise be ignored silently
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	configs := make(hostConfigs, len(raw))
+	for host, settings := range raw {
+		if settings == nil {
+			return nil, fmt.Errorf("%s: no settings", host)
+		}
+		if settings.RPS < 0 {
+			return nil, fmt.Errorf("%s: rps must not be negative: %v", host, settings.RPS)
+		}
+		if settings.Proxy != "" {
+			if settings.proxyURL, err = url.Parse(settings.Proxy); err != nil || settings.proxyURL.Host == "" {
+				return nil, fmt.Errorf("%s: invalid proxy %q", host, settings.Proxy)
+			}
+		}
+		configs[strings.ToLower(strings.TrimSpace(host))] = settings
+	}
+	return configs, nil
+}
+
+// lookup returns the entry matching host: the entry of the host itself or, failing that, of its closest
+// parent domain. The key is empty when no entry matches
+func (c hostConfigs) lookup(host string) (string, *hostSettings) {
+	host = strings.ToLower(host)
+	for host != "" {
+		if settings, ok := c[host]; ok {
+			return host, settings
+		}
+		if net.ParseIP(host) != nil {
+			// An IP address has no parent domain
+			break
+		}
+		_, host, _ = strings.Cut(host, ".")
+	}
+	return "", nil
+}
+
+// proxy returns the proxy of the host of req, falling back to the HTTP_PROXY/HTTPS_PROXY environment
+func (c hostConfigs) proxy(req *http.Request) (*url.URL, error) {
+	if _, settings := c.lookup(req.URL.Hostname()); settings != nil && settings.proxyURL != nil {
+		return settings.proxyURL, nil
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// hostConfigTransport is an http.RoundTripper applying the -host-config headers, User-Agent and rate of
+// the host of each request. Hosts sharing an entry (subdomains) share its rate
+type hostConfigTransport struct {
+	next    http.RoundTripper
+	configs hostConfigs
+
+	mu       sync.Mutex
+	nextSlot map[string]time.Time
+}
+
+// newHostConfigTransport wraps next with the per-host settings of configs
+func newHostConfigTransport(next http.RoundTripper, configs hostConfigs) *hostConfigTransport {
+	return &hostConfigTransport{next: next, configs: configs, nextSlot: make(map[string]time.Time)}
+}
+
+// RoundTrip waits for a slot of the host's rate and sends req with the host's headers
+func (t *hostConfigTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, settings := t.configs.lookup(req.URL.Hostname())
+	if settings == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	if settings.RPS > 0 {
+		if err := t.wait(req, key, time.Duration(float64(time.Second)/settings.RPS)); err != nil {
+			return nil, err
+		}
+	}
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(req.Context())
+	for name, value := range settings.Headers {
+		req.Header.Set(name, value)
+	}
+	// A User-Agent set explicitly, e.g. by -user-agent-file rotation, takes precedence
+	if settings.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", settings.UserAgent)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// wait blocks until the next request slot of the entry key, spacing requests interval apart
+func (t *hostConfigTransport) wait(req *http.Request, key string, interval time.Duration) error {
+	t.mu.Lock()
+	slot := time.Now()
+	if next := t.nextSlot[key]; next.After(slot) {
+		slot = next
+	}
+	t.nextSlot[key] = slot.Add(interval)
+	t.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
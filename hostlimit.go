@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// hostLimiter caps the number of URLs of each host that are in flight at once (-max-concurrent-per-host).
+// The dispatcher of scrapeAll only hands out a URL whose host has a free slot, so workers never sit
+// waiting on a saturated host while URLs of other hosts are pending
+type hostLimiter struct {
+	limit int
+
+	mu       sync.Mutex
+	inFlight map[string]int
+	// freed is signalled whenever a slot is released
+	freed chan struct{}
+}
+
+// newHostLimiter returns a limiter allowing limit URLs per host in flight; 0 means no limit
+func newHostLimiter(limit int) *hostLimiter {
+	return &hostLimiter{limit: limit, inFlight: make(map[string]int), freed: make(chan struct{}, 1)}
+}
+
+// available reports whether host has a free slot. Local files are never limited
+func (h *hostLimiter) available(host string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.limit == 0 || host == "" || h.inFlight[host] < h.limit
+}
+
+// acquire takes a slot of host
+func (h *hostLimiter) acquire(host string) {
+	h.mu.Lock()
+	h.inFlight[host]++
+	h.mu.Unlock()
+}
+
+// release frees a slot of host and wakes up the dispatcher
+func (h *hostLimiter) release(host string) {
+	h.mu.Lock()
+	h.inFlight[host]--
+	h.mu.Unlock()
+	select {
+	case h.freed <- struct{}{}:
+	default:
+	}
+}
+
+// urlHost returns the lowercased host of a URL, or an empty string for local files and unparsable URLs
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// hostQueues holds the URLs still to be dispatched, grouped by host in input order
+type hostQueues struct {
+	hosts  []string
+	queues map[string][]int
+}
+
+// newHostQueues groups the indexes of urls by host
+func newHostQueues(urls []string) *hostQueues {
+	q := &hostQueues{queues: make(map[string][]int)}
+	for i, u := range urls {
+		host := urlHost(u)
+		if _, ok := q.queues[host]; !ok {
+			q.hosts = append(q.hosts, host)
+		}
+		q.queues[host] = append(q.queues[host], i)
+	}
+	return q
+}
+
+// next returns the earliest pending URL whose host has a free slot in limiter, without removing it.
+// ok is false when no such URL exists
+func (q *hostQueues) next(limiter *hostLimiter) (i int, host string, ok bool) {
+	i = -1
+	for _, h := range q.hosts {
+		if pending := q.queues[h]; len(pending) > 0 && (i < 0 || pending[0] < i) && limiter.available(h) {
+			i, host = pending[0], h
+		}
+	}
+	return i, host, i >= 0
+}
+
+// pop removes the earliest pending URL of host
+func (q *hostQueues) pop(host string) {
+	q.queues[host] = q.queues[host][1:]
+}
+
+// push puts URL i of host back into its queue, in input order
+func (q *hostQueues) push(i int, host string) {
+	if _, ok := q.queues[host]; !ok {
+		q.hosts = append(q.hosts, host)
+	}
+	pending := q.queues[host]
+	at, _ := slices.BinarySearch(pending, i)
+	q.queues[host] = slices.Insert(pending, at, i)
+}
+
+// empty reports whether every URL has been dispatched
+func (q *hostQueues) empty() bool {
+	for _, pending := range q.queues {
+		if len(pending) > 0 {
+			return false
+		}
+	}
+	return true
+}
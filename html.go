@@ -0,0 +1,103 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// htmlCell is a table cell of the HTML report. Sort, when set, is the value the column is sorted by
+// instead of the displayed text, e.g. the number of active installations
+type htmlCell struct {
+	Value string
+	Sort  string
+}
+
+// htmlReport is the data rendered by htmlTemplate
+type htmlReport struct {
+	Generated string
+	Headers   []string
+	Rows      [][]htmlCell
+}
+
+// htmlTemplate renders a self-contained report page. Clicking a column header sorts the table by
+// that column, comparing numbers numerically; html/template escapes every value
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>WordPress Plugin Metadata</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2em; color: #1d2327; }
+h1 { font-size: 1.4em; margin-bottom: 0.2em; }
+p.meta { color: #646970; margin-top: 0; }
+table { border-collapse: collapse; font-size: 0.9em; }
+th, td { border: 1px solid #dcdcde; padding: 0.4em 0.6em; text-align: left; vertical-align: top; }
+th { background: #f6f7f7; cursor: pointer; position: sticky; top: 0; white-space: nowrap; user-select: none; }
+th[aria-sort="ascending"]::after { content: " \25B2"; }
+th[aria-sort="descending"]::after { content: " \25BC"; }
+tbody tr:nth-child(even) { background: #fbfbfc; }
+td { max-width: 30em; overflow-wrap: anywhere; }
+</style>
+</head>
+<body>
+<h1>WordPress Plugin Metadata</h1>
+<p class="meta">Plugins: {{len .Rows}} · Generated: {{.Generated}}</p>
+<table>
+<thead>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td{{if .Sort}} data-sort="{{.Sort}}"{{end}}>{{.Value}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("th").forEach(function (th, column) {
+  th.addEventListener("click", function () {
+    var ascending = th.getAttribute("aria-sort") !== "ascending";
+    document.querySelectorAll("th").forEach(function (h) { h.removeAttribute("aria-sort"); });
+    th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+    var tbody = document.querySelector("tbody");
+    var key = function (row) {
+      var cell = row.cells[column];
+      return cell.hasAttribute("data-sort") ? cell.getAttribute("data-sort") : cell.textContent;
+    };
+    var rows = Array.prototype.slice.call(tbody.rows);
+    rows.sort(function (a, b) {
+      var x = key(a), y = key(b);
+      var order = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y, undefined, { numeric: true });
+      return ascending ? order : -order;
+    });
+    rows.forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// exportToHTML exports the scraped plugin metadata as a single self-contained HTML page with a
+// sortable table of the output columns
+func exportToHTML(data []PluginMeta, filename string) error {
+	report := htmlReport{
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Headers:   headerRow(),
+		Rows:      make([][]htmlCell, 0, len(data)),
+	}
+	for _, item := range data {
+		values := pluginRow(item)
+		row := make([]htmlCell, len(values))
+		for i, v := range values {
+			row[i] = htmlCell{Value: v}
+		}
+		if n, ok := item.installCount(); ok {
+			row[installsColumn].Sort = strconv.FormatInt(n, 10)
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		return htmlTemplate.Execute(w, report)
+	})
+}
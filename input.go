@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// inputFormats are the accepted -input-format values
+var inputFormats = []string{"auto", "csv", "json"}
+
+// resolveInputFormat returns the format of the input file, detecting it from the file extension for auto
+func resolveInputFormat(filename, format string) string {
+	if format != "auto" {
+		return format
+	}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		return "json"
+	}
+	return "csv"
+}
+
+// readInput reads plugin URLs and passthrough values from a CSV or JSON input file. strictCSV rejects
+// CSV rows whose number of fields differs from the header
+func readInput(filename, format, field string, passthrough []string, strictCSV bool) ([]string, map[string]map[string]string, error) {
+	if resolveInputFormat(filename, format) == "json" {
+		return readInputJSON(filename, field, passthrough)
+	}
+	return readInputCSV(filename, passthrough, strictCSV)
+}
+
+// readInputGlob reads the plugins of every input file matching pattern (see readInput), in file name order.
+// A plugin listed in several files is kept once, at its first occurrence. Besides the URLs and passthrough
+// values it returns the file each plugin was taken from, keyed by plugin slug
+func readInputGlob(pattern, format, field string, passthrough []string, strictCSV bool) ([]string, map[string]map[string]string, map[string]string, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil, nil, fmt.Errorf("no files match %q", pattern)
+	}
+
+	var urls []string
+	extras := make(map[string]map[string]string)
+	sources := make(map[string]string)
+	seen := make(map[string]bool)
+	duplicates := 0
+	for _, file := range files {
+		fileURLs, fileExtras, err := readInput(file, format, field, passthrough, strictCSV)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", file, err)
+		}
+		log.Printf("Read %d URLs from %s", len(fileURLs), file)
+		for _, u := range fileURLs {
+			slug := pluginSlug(u)
+			key := urlKey(u)
+			if seen[key] {
+				duplicates++
+				continue
+			}
+			seen[key] = true
+			urls = append(urls, u)
+			sources[slug] = file
+			if values, ok := fileExtras[slug]; ok {
+				extras[slug] = values
+			}
+		}
+	}
+	if duplicates > 0 {
+		log.Printf("Skipped %d URLs listed in more than one of the %d input files", duplicates, len(files))
+	}
+	return urls, extras, sources, nil
+}
+
+// readInputJSON reads plugin URLs from a JSON array of objects, taking each plugin from the value at the
+// dotted field path (e.g. plugin.slug). The value may be a plugin URL or a bare slug. Passthrough columns
+// are looked up as field paths of the same object
+func readInputJSON(filename, field string, passthrough []string) ([]string, map[string]map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, nil, fmt.Errorf("%s: expected a JSON array of objects: %v", filename, err)
+	}
+
+	var urls []string
+	extras := make(map[string]map[string]string)
+	for i, item := range items {
+		value, ok := jsonFieldValue(item, field)
+		if !ok || value == "" {
+			return nil, nil, fmt.Errorf("%s: element %d has no %q field", filename, i, field)
+		}
+		u := value
+		if !strings.Contains(value, "/") {
+			u = canonicalPluginURL(value)
+		}
+		if err := checkInputURL(u); err != nil {
+			log.Printf("Warning: Skipping element %d of %s: %v", i, filename, err)
+			continue
+		}
+		urls = append(urls, u)
+		if len(passthrough) > 0 {
+			values := make(map[string]string, len(passthrough))
+			for _, name := range passthrough {
+				values[name], _ = jsonFieldValue(item, name)
+			}
+			extras[pluginSlug(u)] = values
+		}
+	}
+	return urls, extras, nil
+}
+
+// jsonFieldValue returns the value at a dotted field path of a decoded JSON object as a string.
+// Keys are matched case-insensitively when there is no exact match
+func jsonFieldValue(obj map[string]any, path string) (string, bool) {
+	var value any = obj
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if value, ok = m[key]; !ok {
+			for k, v := range m {
+				if strings.EqualFold(k, key) {
+					value, ok = v, true
+					break
+				}
+			}
+			if !ok {
+				return "", false
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// jsonFilenames returns the file each plugin is written to by -json-per-file: {slug}.json in dir, with
+// characters other than letters, digits, '.', '_' and '-' replaced. Plugins without a slug are named after
+// their position, and a name already taken (compared case-insensitively, for case-insensitive filesystems)
+// gets a numeric suffix, e.g. akismet-2.json, so no plugin overwrites another. With a shards depth above 0
+// the files are spread over that many levels of subdirectories named after the first characters of the
+// file name (see shardPath)
+func jsonFilenames(data []PluginMeta, dir, ext string, shards int) []string {
+	taken := make(map[string]bool, len(data))
+	names := make([]string, len(data))
+	for i, item := range data {
+		base := strings.Map(func(r rune) rune {
+			if r == '.' || r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+				return r
+			}
+			return '-'
+		}, item.Slug)
+		if strings.Trim(base, ".") == "" {
+			base = fmt.Sprintf("plugin-%d", i+1)
+		}
+		name := base
+		for n := 2; taken[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken[strings.ToLower(name)] = true
+		names[i] = joinOutputPath(dir, shardPath(name, shards)+ext)
+	}
+	return names
+}
+
+// shardPath prefixes name with depth levels of subdirectories named after its first characters, lowercased
+// so that names differing only in case share a directory, e.g. a/k/akismet for depth 2. Names shorter than
+// depth are padded with '_', as are dots, so no directory is named . or ..
+func shardPath(name string, depth int) string {
+	lower := strings.ToLower(name)
+	parts := make([]string, 0, depth+1)
+	for i := 0; i < depth; i++ {
+		c := "_"
+		if i < len(lower) && lower[i] != '.' {
+			c = lower[i : i+1]
+		}
+		parts = append(parts, c)
+	}
+	return strings.Join(append(parts, name), "/")
+}
+
+// joinOutputPath joins a file name to a local directory or an object store prefix such as s3://bucket/plugins
+func joinOutputPath(dir, name string) string {
+	if _, remote := objectStoreScheme(dir); remote {
+		return strings.TrimSuffix(dir, "/") + "/" + name
+	}
+	return filepath.Join(dir, name)
+}
+
+// exportToJSONFiles writes each plugin as a JSON object to its own file in dir (see jsonFilenames),
+// gzipped when compress is set and sharded into subdirectories shards levels deep. Every file is written atomically
+func exportToJSONFiles(data []PluginMeta, dir string, compress bool, shards int) error {
+	_, remote := objectStoreScheme(dir)
+	if !remote {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	for i, filename := range jsonFilenames(data, dir, jsonFileExt(compress), shards) {
+		if !remote && shards > 0 {
+			if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+				return err
+			}
+		}
+		body, err := marshalPlugin(data[i], "")
+		if err != nil {
+			return err
+		}
+		err = writeFileAtomic(filename, func(w io.Writer) error {
+			if _, err := w.Write(body); err != nil {
+				return err
+			}
+			_, err := io.WriteString(w, "\n")
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	return nil
+}
+
+// jsonFileExt returns the extension of the -json-per-file files
+func jsonFileExt(compress bool) string {
+	if compress {
+		return ".json.gz"
+	}
+	return ".json"
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// languagesSeeAllPattern matches the truncated button text giving the total, e.g. "See all 42"
+var languagesSeeAllPattern = regexp.MustCompile(`(?i)see all (\d+)`)
+
+// languagesMorePattern matches the truncated text naming the first languages, e.g. "English (US), German and 12 more"
+var languagesMorePattern = regexp.MustCompile(`(?i)^(.+) and (\d+) more`)
+
+// extractLanguages extracts the supported languages from the Languages item of the meta widget as a
+// comma-separated list, along with their number. The button only shows a truncated text such as
+// "See all 42", so the complete list is taken from the widget's hidden list or popover links when the
+// page has one. Otherwise the button text is returned, and the number is parsed from it (0 when it
+// doesn't give one)
+func extractLanguages(s *goquery.Selection) (string, int) {
+	widget := s.Find(".languages")
+	items := widget.Find("ul li")
+	if items.Length() == 0 {
+		// The popover links each language to its locale site; the "Translate into your language" link
+		// points to translate.wordpress.org instead
+		items = widget.Find(".popover a").FilterFunction(func(i int, a *goquery.Selection) bool {
+			href, _ := a.Attr("href")
+			return !strings.Contains(href, "translate.wordpress.org")
+		})
+	}
+
+	var languages []string
+	items.Each(func(i int, item *goquery.Selection) {
+		if name := strings.Join(strings.Fields(item.Text()), " "); name != "" {
+			languages = append(languages, name)
+		}
+	})
+	if len(languages) > 0 {
+		return strings.Join(languages, ", "), len(languages)
+	}
+
+	text := strings.TrimSpace(s.Find("button").First().Text())
+	if m := languagesSeeAllPattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return text, n
+	}
+	if m := languagesMorePattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return text, len(strings.Split(m[1], ",")) + n
+	}
+	return text, 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyRecorder collects the duration of every request attempt. It is safe for concurrent use by the workers
+type latencyRecorder struct {
+	mu        sync.Mutex
+	durations []time.Duration
+}
+
+// record adds the duration of an attempt. A nil latencyRecorder records nothing
+func (l *latencyRecorder) record(d time.Duration) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.durations = append(l.durations, d)
+}
+
+// summary formats the min/median/p90/p99/max latency of the recorded attempts
+func (l *latencyRecorder) summary() string {
+	l.mu.Lock()
+	sorted := slices.Clone(l.durations)
+	l.mu.Unlock()
+	if len(sorted) == 0 {
+		return "no requests recorded"
+	}
+	slices.Sort(sorted)
+
+	round := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	return fmt.Sprintf("%d requests: min=%v median=%v p90=%v p99=%v max=%v",
+		len(sorted), round(sorted[0]), round(percentile(sorted, 50)), round(percentile(sorted, 90)),
+		round(percentile(sorted, 99)), round(sorted[len(sorted)-1]))
+}
+
+// percentile returns the pth percentile of sorted durations using the nearest-rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -1,35 +1,177 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"net/http"
+	"net/http/httptrace"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/term"
 )
 
-// PluginMeta represents the metadata of a WordPress plugin
+// exitNoURLs is the exit status when there are no URLs to scrape, distinct from failed runs (1) and usage errors (2)
+const exitNoURLs = 3
+
+// exitInterrupted is the exit status of a run stopped by an interrupt, after exporting its partial results
+const exitInterrupted = 130
+
+// PluginMeta represents the metadata of a WordPress plugin.
+// The csv tag names the output column (columns are written in field order),
+// the json tag the JSON property and the desc tag documents the field in the JSON Schema.
+// The source tag names where the field comes from when it isn't the page itself (see fieldSources).
+// Fields hold typed values where the scraper parses them (times, counts, status codes, ...); how each
+// type is written is up to the type itself (see formatCell and the MarshalJSON methods), not the writers
 type PluginMeta struct {
-	URL         string `default:"N/A"`
-	Name        string `default:"Unknown"`
-	Version     string `default:"0.0.0"`
-	LastUpdated string `default:"N/A"`
-	Installs    string `default:"N/A"`
-	WPVersion   string `default:"N/A"`
-	TestedUpTo  string `default:"N/A"`
-	PHPVersion  string `default:"N/A"`
-	Languages   string `default:"N/A"`
-	Tags        string `default:"N/A"`
+	URL         string `default:"N/A" source:"input" csv:"URL" json:"url" desc:"URL of the scraped plugin page"`
+	Slug        string `default:"N/A" source:"input" csv:"Slug" json:"slug" desc:"Plugin slug, e.g. akismet"`
+	Name        string `default:"Unknown" csv:"Name" json:"name" desc:"Plugin name"`
+	Version     string `default:"0.0.0" csv:"Version" json:"version" desc:"Current plugin version"`
+	LastUpdated string `default:"N/A" csv:"Last Updated" json:"last_updated" desc:"When the plugin was last updated, as displayed (e.g. 2 weeks ago)"`
+	Installs    string `default:"N/A" csv:"Active Installations" json:"installs" desc:"Active installations, as displayed (e.g. 5+ million)"`
+	InstallTier string `default:"N/A" csv:"Install Tier" json:"install_tier" desc:"Active installations normalized to a canonical tier (e.g. 5+ million)"`
+	WPVersion   string `default:"N/A" csv:"WordPress Version" json:"wp_version" desc:"Minimum required WordPress version, as displayed"`
+	TestedUpTo  string `default:"N/A" csv:"Tested Up To" json:"tested_up_to" desc:"Latest WordPress version the plugin was tested with, as displayed"`
+
+	// StableTag is the readme's Stable tag, or Version when the page doesn't expose it
+	StableTag         string `csv:"Stable Tag" json:"stable_tag" desc:"Stable tag of the readme, taken from the download link (trunk for plugins released from trunk); the displayed version when the page has no download link"`
+	StableTagMismatch bool   `csv:"Stable Tag Mismatch" json:"stable_tag_mismatch" desc:"Whether the stable tag differs from the displayed version, a sign of a release in an inconsistent state"`
+
+	// CanonicalURL is the join key of the output: unlike URL (as given in the input) and FinalURL (after
+	// redirects), it is the same for every row of a plugin however its page was reached
+	CanonicalURL string `csv:"Canonical URL" json:"canonical_url" desc:"Canonical wordpress.org URL built from the slug, https://wordpress.org/plugins/<slug>/; the key to join rows on"`
+	FinalURL     string `csv:"Final URL" json:"final_url" desc:"URL of the page actually scraped, after redirects; empty for failed pages"`
+	HomepageURL  string `csv:"Homepage URL" json:"homepage_url" desc:"The plugin's external homepage as linked from its page, empty when absent"`
+
+	// InstallCount is only set when the page has a more precise figure than the displayed Installs
+	InstallCount *InstallCount `csv:"Install Count" json:"install_count,omitempty" desc:"Exact number of active installations from a title or data attribute of the installations element, absent when the page only shows the rounded figure"`
+
+	// InstallsAsOf is when the installations figure was computed. Pages rarely say, so it is usually
+	// FetchedAt with InstallsAsOfApprox set
+	InstallsAsOf       Timestamp `csv:"Installs As Of" json:"installs_as_of" desc:"When the active installations figure was computed, in RFC 3339 format (UTC), from a date on the installations element, otherwise when the page was scraped (see installs_as_of_approximate); empty when the installations are unknown"`
+	InstallsAsOfApprox bool      `csv:"Installs As Of Approximate" json:"installs_as_of_approximate" desc:"Whether installs_as_of is only the time the page was scraped, the page giving no date for the figure"`
+
+	// InstallsLog10 is nil unless -installs-log10 is set
+	InstallsLog10 *LogScale `csv:"Installs Log10" json:"installs_log10,omitempty" desc:"Base-10 logarithm of the active installations lower bound (0 for fewer than 10), absent when they couldn't be parsed (only with -installs-log10)"`
+
+	// Compat is the WordPress version window parsed from WPVersion and TestedUpTo
+	Compat CompatRange `json:"compat" desc:"WordPress compatibility range as normalized version numbers"`
+
+	PHPVersion string    `default:"N/A" csv:"PHP Version" json:"php_version" desc:"Minimum required PHP version, as displayed"`
+	Languages  string    `default:"N/A" csv:"Languages" json:"languages" desc:"Supported languages, comma-separated when the page has the full list, otherwise as displayed (e.g. See all 42)"`
+	Tags       string    `default:"N/A" csv:"Tags" json:"tags" desc:"Plugin tags"`
+	IconURL    string    `csv:"Icon URL" json:"icon_url" desc:"URL of the plugin icon (highest resolution available), empty when absent"`
+	BannerURL  string    `csv:"Banner URL" json:"banner_url" desc:"URL of the plugin banner (highest resolution available), empty when absent"`
+	DonateURL  string    `csv:"Donate URL" json:"donate_url" desc:"URL of the plugin's donate/funding link, empty when absent"`
+	FetchedAt  Timestamp `csv:"Fetched At" json:"fetched_at" desc:"When the page was scraped, in RFC 3339 format (UTC); empty for failed pages"`
+
+	// LanguageCount is 0 when neither the full list nor the button text gives the number of languages
+	LanguageCount int `csv:"Language Count" json:"language_count,omitempty" desc:"Number of supported languages, from the full list or the truncated button text (e.g. See all 42); absent when unknown"`
+
+	// HTTPStatus is recorded for failed pages too, and is the outcome of the row with -record-status
+	HTTPStatus HTTPStatus `csv:"HTTP Status" json:"http_status,omitempty" desc:"HTTP status code of the plugin page, empty when no response was received"`
+	// FinalSlug is only set when redirects ended on a page with a different slug than the one requested
+	FinalSlug string `csv:"Final Slug" json:"final_slug,omitempty" desc:"Slug of the page reached after redirects when it differs from the requested slug, e.g. a renamed plugin; empty when they match"`
+
+	// UntestedWarning is set when the page warns that the plugin hasn't been tested with recent WordPress releases
+	UntestedWarning     bool   `csv:"Untested Warning" json:"untested_warning" desc:"Whether the page warns that the plugin hasn't been tested with the latest major WordPress releases"`
+	UntestedWarningText string `csv:"Untested Warning Text" json:"untested_warning_text" desc:"Text of the untested warning, empty when absent"`
+
+	// Description is empty unless -description is set
+	Description string `csv:"Description" json:"description,omitempty" desc:"Full description as plain text, truncated to -description-max characters (only with -description)"`
+
+	// IsFreemium is set when the page advertises a paid pro/premium version (see detectFreemium)
+	IsFreemium       bool   `csv:"Is Freemium" json:"is_freemium" desc:"Whether the plugin page advertises a paid pro/premium version"`
+	FreemiumEvidence string `csv:"Freemium Evidence" json:"freemium_evidence" desc:"The signal IsFreemium is based on, e.g. link: Upgrade to Pro; empty when not freemium"`
+
+	// CompatibilityVotes is nil unless the page shows the legacy compatibility widget
+	CompatibilityVotes *CompatibilityVotes `csv:"Compatibility Votes" json:"compatibility_votes,omitempty" desc:"Works/broken compatibility votes for the displayed versions, absent when the page has none"`
+
+	// SupportStats is nil unless the page shows the support widget's resolved threads counter
+	SupportURL   string        `csv:"Support URL" json:"support_url" desc:"URL of the plugin's support forum, empty when absent"`
+	SupportStats *SupportStats `csv:"Support Stats" json:"support_stats,omitempty" desc:"Support threads resolved out of those opened in the last two months, absent when the page has none"`
+
+	// FAQ is nil unless -faq is set
+	FAQ FAQ `csv:"FAQ Items" json:"faq,omitempty" desc:"Question/answer pairs of the FAQ section (at most 20, only with -faq)"`
+
+	// PreviousVersions lists the versions offered in the "Previous versions" download dropdown, newest first
+	PreviousVersions []string `csv:"Previous Versions" json:"previous_versions" desc:"Versions offered for download in the previous versions dropdown (at most 100), empty when absent"`
+
+	// VersionStats maps each plugin version to its percentage of active installs ("Advanced View")
+	VersionStats VersionStats `source:"api" csv:"Version Stats" json:"version_stats,omitempty" desc:"Percentage of active installs per plugin version (only with -advanced-stats)"`
+
+	// FirstReleased, LatestReleased and ReleaseCount are empty unless -release-dates is set
+	FirstReleased  *Date `source:"api" csv:"First Released" json:"first_released,omitempty" desc:"Date the plugin was added to the directory, YYYY-MM-DD (only with -release-dates)"`
+	LatestReleased *Date `source:"api" csv:"Latest Released" json:"latest_released,omitempty" desc:"Date of the plugin's latest release, YYYY-MM-DD (only with -release-dates)"`
+	ReleaseCount   int   `source:"api" csv:"Release Count" json:"release_count,omitempty" desc:"Number of versions in the API's version history, trunk excluded (only with -release-dates)"`
+
+	// SourceFile is empty unless -input-glob is set
+	SourceFile string `source:"input" csv:"Source File" json:"source_file,omitempty" desc:"Input file the plugin was listed in (only with -input-glob; the first one when listed in several)"`
+
+	// ChangeType and ChangedFields are empty unless -baseline is set
+	ChangeType    string   `csv:"Change Type" json:"change_type,omitempty" desc:"How the row differs from the -baseline output: added (a plugin missing from it) or changed"`
+	ChangedFields []string `csv:"Changed Fields" json:"changed_fields,omitempty" desc:"Columns whose value differs from the -baseline output, for changed rows"`
+
+	// Passthrough holds the -passthrough-columns values copied from the input row
+	Passthrough map[string]string `source:"input" csv:"-" json:"passthrough,omitempty" desc:"Input CSV columns copied with -passthrough-columns"`
+
+	// Provenance records the fields taken from a fallback during extraction, by field name, for -json-provenance
+	Provenance map[string]string `csv:"-" json:"-"`
 }
 
 func main() {
+	cfg, err := parseFlags()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if cfg.PrintSchema {
+		if err := printJSONSchema(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if cfg.ListFields {
+		if err := listFields(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	csvDelimiter, csvQuoteAll = cfg.Delimiter, cfg.QuoteAll
+	maxURLLength = cfg.MaxURLLength
+
+	if cfg.Merge != "" {
+		rows, err := mergeResultFiles(cfg.MergeInputs, cfg.Merge)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to merge:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Merged %d files into %s (%d rows)\n", len(cfg.MergeInputs), cfg.Merge, rows)
+		return
+	}
+
 	// Reset log file
 	logFile, err := os.Create("scraper.log")
 	if err != nil {
@@ -43,88 +185,836 @@ func main() {
 
 	log.Println("Starting scraping process")
 
-	// Read CSV file containing URL list
-	urls, err := readURLsFromCSV("plugin_urls.csv")
+	if cfg.Pprof != "" {
+		if err := startPprof(cfg.Pprof); err != nil {
+			log.Fatal("Failed to start pprof server:", err)
+		}
+	}
+
+	passthroughColumns = cfg.PassthroughColumns
+	quietLog = cfg.QuietHTTP
+	renames = cfg.Renames
+	jsonProvenance = cfg.JSONProvenance
+	// Installs Log10 is only written when asked for, as JSON omits the key without it
+	if !cfg.InstallsLog10 {
+		setOutputColumns(withoutColumns(outputColumns, "Installs Log10"))
+	}
+
+	httpClient, err = newHTTPClient(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure HTTP client:", err)
+	}
+	if cfg.TLSInsecureSkipVerify {
+		log.Println("Warning: TLS certificate verification is disabled")
+	}
+	if cfg.StrictSecurity {
+		log.Printf("Strict security: https only, certificates verified, TLS %s or later", cfg.TLSMinVersion)
+	}
+
+	if cfg.DumpSelectors != "" {
+		if err := dumpSelectors(os.Stdout, cfg.DumpSelectors); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to fetch %s: %v\n", cfg.DumpSelectors, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Read CSV file containing URL list, the failures of a previous run, a directory of saved pages
+	// or crawl a plugin directory listing
+	var urls []string
+	var input string
+	var extras map[string]map[string]string
+	var sources map[string]string
+	var replayed []PluginMeta
+	switch {
+	case cfg.Replay != "":
+		input = cfg.Replay
+		replayed, err = readReplay(cfg.Replay)
+		for _, meta := range replayed {
+			urls = append(urls, meta.URL)
+		}
+	case cfg.FromDir != "":
+		input = cfg.FromDir
+		urls, err = localPageURLs(cfg.FromDir)
+	case cfg.OnlyFailed:
+		input = errorsReportFile
+		urls, err = readURLsFromCSV(errorsReportFile)
+	case cfg.Browse != "" || cfg.Search != "":
+		input = browsePageURL(cfg.Browse, cfg.Search, 1)
+		urls, err = crawlPluginURLs(cfg.Browse, cfg.Search, cfg.BrowsePages, cfg.DelayMin, cfg.DelayMax)
+	case cfg.InputGlob != "":
+		input = cfg.InputGlob
+		urls, extras, sources, err = readInputGlob(cfg.InputGlob, cfg.InputFormat, cfg.InputField, cfg.PassthroughColumns, cfg.StrictCSV)
+	default:
+		input = cfg.Input
+		urls, extras, err = readInput(input, cfg.InputFormat, cfg.InputField, cfg.PassthroughColumns, cfg.StrictCSV)
+	}
 	if err != nil {
 		log.Fatal("Failed to read URLs:", err)
 	}
 
 	log.Printf("Loaded %d URLs", len(urls))
+	if len(urls) == 0 {
+		log.Printf("No URLs found in %s", input)
+		fmt.Fprintf(os.Stderr, "No URLs found in input (%s); nothing to scrape\n", input)
+		os.Exit(exitNoURLs)
+	}
+
+	// Replayed rows were scraped already, so their URLs are neither normalized nor filtered
+	if cfg.NormalizeURL && replayed == nil {
+		urls = canonicalizeURLs(urls, cfg.Locale, cfg.EnforceHTTPS)
+	}
+
+	if replayed != nil {
+		log.Printf("Replaying %d rows from %s without network access", len(replayed), input)
+	} else if urls = filterAllowedURLs(urls, cfg.AllowHosts, cfg.FromDir != ""); len(urls) == 0 {
+		fmt.Fprintf(os.Stderr, "No URLs left to scrape: no URL in %s has an allowed host (see -allow-host)\n", input)
+		os.Exit(exitNoURLs)
+	}
+
+	// Input URLs are https after normalization; without it a plain-HTTP URL must not quietly fail
+	// one row at a time
+	if cfg.StrictSecurity && replayed == nil {
+		if insecure := nonHTTPSURLs(urls); len(insecure) > 0 {
+			fmt.Fprintf(os.Stderr, "-strict-security: %d input URLs are not https, e.g. %s\n", len(insecure), insecure[0])
+			os.Exit(2)
+		}
+	}
+
+	urls = windowURLs(urls, cfg.Skip, cfg.SampleEvery, cfg.Limit)
+	if cfg.Skip > 0 || cfg.SampleEvery > 1 || cfg.Limit > 0 {
+		log.Printf("Processing %d URLs after applying skip=%d, sample-every=%d, limit=%d", len(urls), cfg.Skip, cfg.SampleEvery, cfg.Limit)
+	}
+	if len(urls) == 0 {
+		fmt.Fprintf(os.Stderr, "No URLs left to scrape after -skip %d (input has fewer URLs)\n", cfg.Skip)
+		os.Exit(exitNoURLs)
+	}
+
+	if cfg.Cookie != "" {
+		if err := addCookieHeader(httpClient.Jar, cfg.Cookie, urls); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	// Fetch plugin information for each URL
-	var pluginMetas []PluginMeta
-	for _, url := range urls {
-		log.Printf("Processing URL: %s", url)
-		meta, err := scrapePluginMetaWithRetry(url, 3) // Maximum 3 retries
+	opts := scrapeOptions{
+		DumpMetaItems:     cfg.DumpMetaItems,
+		NameFromTitle:     cfg.NameFromTitle,
+		FAQ:               cfg.FAQ,
+		RetryOnParseError: cfg.RetryOnParseError,
+		StrictSlug:        cfg.StrictSlug,
+		NoDefaults:        cfg.NoDefaults,
+		ArchiveDir:        cfg.ArchiveDir,
+		ArchiveGzip:       cfg.ArchiveGzip,
+		LogConnections:    cfg.LogConnections,
+		RetryAfterMax:     cfg.RetryAfterMax,
+		Requeue:           cfg.RequeueThrottled,
+		UserAgents:        cfg.UserAgents,
+		Replay:            replayed,
+		Sources:           sources,
+	}
+	if cfg.Description {
+		opts.DescriptionMax = cfg.DescriptionMax
+	}
+	if cfg.LatencyStats {
+		opts.Latency = &latencyRecorder{}
+	}
+	if cfg.ArchiveDir != "" {
+		if err := os.MkdirAll(cfg.ArchiveDir, 0755); err != nil {
+			log.Fatal("Failed to create archive directory:", err)
+		}
+	}
+	if cfg.Baseline != "" {
+		opts.Baseline, err = readBaseline(cfg.Baseline)
+		if err != nil {
+			log.Fatal("Failed to read baseline:", err)
+		}
+	}
+	if cfg.Conditional != "" {
+		opts.Conditional, err = loadConditionalCache(cfg.Conditional, opts.fingerprint())
+		if err != nil {
+			log.Fatal("Failed to read conditional cache:", err)
+		}
+	}
+	if cfg.AuditLog != "" {
+		opts.Audit, err = newAuditLog(cfg.AuditLog)
 		if err != nil {
-			log.Printf("Warning: Error processing %s: %v", url, err)
+			log.Fatal("Failed to create audit log:", err)
 		}
-		pluginMetas = append(pluginMetas, meta)
-		log.Printf("Completed processing URL: %s", url)
-		time.Sleep(time.Duration(rand.Intn(5)+1) * time.Second) // Random wait time of 1-5 seconds
+		defer opts.Audit.Close()
 	}
 
-	// Export results to CSV
-	err = exportToCSV(pluginMetas, "plugin_meta_results.csv")
-	if err != nil {
-		log.Fatal("Failed to export to CSV:", err)
+	// An interrupt stops dispatching URLs and gives the URLs in flight -shutdown-grace to finish;
+	// the results scraped so far are still exported. A second interrupt exits immediately
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupts
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		log.Println("Interrupted")
+		fmt.Fprintln(os.Stderr, "Interrupted: finishing the URLs in flight and exporting the results (interrupt again to quit immediately)")
+		cancel()
+	}()
+
+	if cfg.Watch == 0 {
+		status := runCycle(ctx, cfg, urls, extras, input, opts)
+		if status == 0 && ctx.Err() != nil {
+			status = exitInterrupted
+		}
+		if status != 0 {
+			os.Exit(status)
+		}
+		return
+	}
+
+	// In -watch mode, scrape again every interval until interrupted. An interrupt during a cycle
+	// stops it early like a single run
+	for {
+		runCycle(ctx, cfg, urls, extras, input, opts)
+		log.Printf("Next cycle in %v", cfg.Watch)
+		select {
+		case <-ctx.Done():
+			log.Println("Watch mode stopped")
+			return
+		case <-time.After(cfg.Watch):
+		}
+	}
+}
+
+// runCycle scrapes urls once, exports the results and reports the outcome. It returns the exit status
+// of the cycle. In -watch mode each cycle writes a snapshot file named after its start time
+func runCycle(ctx context.Context, cfg Config, urls []string, extras map[string]map[string]string, input string, opts scrapeOptions) int {
+	log.Printf("Scraping %d URLs", len(urls))
+	startedAt := time.Now()
+	if opts.Latency != nil {
+		opts.Latency = &latencyRecorder{}
+	}
+	reusedBefore := opts.Conditional.Reused()
+
+	var progress progressReporter
+	if cfg.TUI {
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			progress = newTUIView(len(urls))
+		} else {
+			log.Println("stdout is not a terminal, -tui falls back to a plain progress log")
+			progress = &progressLog{out: os.Stdout, total: len(urls)}
+		}
+	}
+
+	// Fetch plugin information for each URL, aborting once too many fail
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	failureLimit := cfg.failureLimit(len(urls))
+	var failed int
+	aborted := false
+	scrape := scrapeAll
+	if opts.Replay != nil {
+		scrape = func(context.Context, []string, Config, scrapeOptions, func(urlResult)) []urlResult {
+			return replayResults(opts.Replay)
+		}
+	}
+	results := scrape(ctx, urls, cfg, opts, func(r urlResult) {
+		if progress != nil {
+			progress.record(r.URL, r.Err, r.Took)
+		}
+		if r.Err != nil {
+			failed++
+			if failureLimit > 0 && failed >= failureLimit && !aborted {
+				aborted = true
+				log.Printf("Aborting: %d URLs failed, reaching the -max-failures limit of %d", failed, failureLimit)
+				cancel()
+			}
+		}
+	})
+	if progress != nil {
+		progress.close()
+	}
+
+	var pluginMetas, scraped []PluginMeta
+	var failures []scrapeFailure
+	var incomplete int
+	for _, r := range results {
+		if values, ok := extras[pluginSlug(r.URL)]; ok {
+			r.Meta.Passthrough = values
+		}
+		if source, ok := opts.Sources[pluginSlug(r.URL)]; ok {
+			r.Meta.SourceFile = source
+		}
+		if r.Keep {
+			pluginMetas = append(pluginMetas, r.Meta)
+		}
+		if r.Err != nil {
+			failures = append(failures, scrapeFailure{URL: r.URL, Err: r.Err})
+		} else {
+			scraped = append(scraped, r.Meta)
+		}
+		if r.Incomplete {
+			incomplete++
+		}
+	}
+
+	if cfg.Dedup {
+		deduped := dedupPlugins(pluginMetas)
+		if n := len(pluginMetas) - len(deduped); n > 0 {
+			log.Printf("Removed %d duplicate rows", n)
+		}
+		pluginMetas = deduped
+		scraped = dedupPlugins(scraped)
+	}
+
+	if opts.Baseline != nil {
+		changed, unchanged := diffAgainstBaseline(pluginMetas, opts.Baseline)
+		log.Printf("Compared with -baseline %s: %d rows added or changed, %d unchanged rows left out", cfg.Baseline, len(changed), unchanged)
+		pluginMetas = changed
+	}
+
+	outputFile := cfg.Output
+	if cfg.JSONPerFile != "" {
+		outputFile = strings.TrimSuffix(cfg.JSONPerFile, "/")
+	} else if outputFile == "" && cfg.OutputTemplate != nil {
+		outputFile = "-"
+	} else if outputFile == "" {
+		outputFile = "plugin_meta_results." + cfg.Format
+	}
+	if cfg.Compress && cfg.JSONPerFile == "" && !strings.HasSuffix(outputFile, ".gz") {
+		outputFile += ".gz"
+	}
+	if cfg.Watch > 0 {
+		outputFile = snapshotFilename(outputFile, startedAt)
+	}
+	// The run metadata and group counts stay local, next to a local output file
+	sidecarBase := outputFile
+	if _, remote := objectStoreScheme(outputFile); remote {
+		sidecarBase = filepath.Base(outputFile)
+	} else if outputFile == "-" {
+		sidecarBase = "plugin_meta_results"
+	}
+	metadataFile := runMetadataFilename(sidecarBase)
+	groupsFile := sidecarFilename(sidecarBase, ".groups."+cfg.GroupByFormat)
+	if cfg.StatsOnly {
+		// Print aggregates of the successfully scraped plugins instead of writing rows
+		if err := printSummary(os.Stdout, summarizePlugins(scraped, time.Now()), cfg.StatsFormat); err != nil {
+			log.Fatalf("Failed to print stats: %v", err)
+		}
+		outputFile = ""
+	} else {
+		// Export results in the selected format
+		export := exporters[cfg.Format]
+		if cfg.OutputTemplate != nil {
+			export = exportWithTemplate(cfg.OutputTemplate)
+		} else if cfg.JSONPerFile != "" {
+			export = func(data []PluginMeta, dir string) error {
+				return exportToJSONFiles(data, dir, cfg.Compress, cfg.ShardDirs)
+			}
+		}
+		var err error
+		if cfg.OnlyFailed {
+			err = mergeIntoCSV(pluginMetas, outputFile)
+		} else if cfg.SplitSize > 0 {
+			err = exportToSplitFiles(pluginMetas, outputFile, cfg.SplitSize, export)
+		} else {
+			err = export(pluginMetas, outputFile)
+		}
+		if err != nil && cfg.JSONPerFile != "" {
+			log.Fatalf("Failed to write JSON files to %s: %v", outputFile, err)
+		} else if err != nil && cfg.OutputTemplate != nil {
+			log.Fatalf("Failed to render %s: %v", cfg.Template, err)
+		} else if err != nil {
+			log.Fatalf("Failed to export to %s: %v", strings.ToUpper(cfg.Format), err)
+		}
+	}
+
+	healthWarnings := defaultedFieldWarnings(scraped, cfg.DefaultWarnThreshold)
+	for _, warning := range healthWarnings {
+		log.Printf("Warning: %s", warning)
+	}
+
+	// A replay has no failures of its own and leaves the errors report of the run it replays alone
+	if opts.Replay == nil {
+		if err := exportErrorsReport(failures, errorsReportFile); err != nil {
+			log.Printf("Warning: Failed to write errors report: %v", err)
+		}
+	}
+	if len(failures) > 0 {
+		log.Printf("%d URLs failed; see %s and re-run them with -only-failed", len(failures), errorsReportFile)
+	}
+
+	if cfg.RunMetadata {
+		runMeta := RunMetadata{
+			Version:    scraperVersion(),
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Input:      input,
+			Output:     outputFile,
+			Format:     cfg.Format,
+			URLs:       len(urls),
+			Rows:       len(pluginMetas),
+			Failures:   len(failures),
+			Options:    resolvedOptions(),
+		}
+		if err := writeRunMetadata(runMeta, metadataFile); err != nil {
+			log.Printf("Warning: Failed to write run metadata: %v", err)
+		}
+	}
+
+	var groups []countRow
+	if cfg.GroupBy != "" {
+		groups = groupPlugins(scraped, cfg.GroupByDimension)
+		if err := writeGroups(groups, cfg.GroupBy, cfg.GroupByFormat, groupsFile); err != nil {
+			log.Printf("Warning: Failed to write group counts: %v", err)
+		}
+	}
+
+	if cfg.Manifest && !cfg.StatsOnly {
+		var entries []manifestEntry
+		if cfg.OnlyFailed {
+			entries = append(entries, manifestEntry{path: outputFile})
+		} else if cfg.JSONPerFile != "" {
+			for _, filename := range jsonFilenames(pluginMetas, outputFile, jsonFileExt(cfg.Compress), cfg.ShardDirs) {
+				rows := 1
+				entries = append(entries, manifestEntry{path: filename, rows: &rows})
+			}
+		} else {
+			entries = outputManifestEntries(outputFile, len(pluginMetas), cfg.SplitSize)
+		}
+		if len(failures) > 0 {
+			rows := len(failures)
+			entries = append(entries, manifestEntry{path: errorsReportFile, rows: &rows})
+		}
+		if cfg.RunMetadata {
+			entries = append(entries, manifestEntry{path: metadataFile})
+		}
+		if cfg.GroupBy != "" {
+			rows := len(groups)
+			entries = append(entries, manifestEntry{path: groupsFile, rows: &rows})
+		}
+		if err := writeManifest(entries, startedAt, manifestFilename(outputFile)); err != nil {
+			log.Printf("Warning: Failed to write manifest: %v", err)
+		}
+	}
+
+	if opts.Conditional != nil {
+		log.Printf("%d pages unchanged since they were last scraped, reused their previous rows", opts.Conditional.Reused()-reusedBefore)
+		if err := opts.Conditional.save(); err != nil {
+			log.Printf("Warning: Failed to write conditional cache: %v", err)
+		}
 	}
 
 	log.Println("Scraping process completed")
-	fmt.Println("Plugin metadata exported to CSV. Please check the log file for details.")
+	if opts.Latency != nil {
+		log.Printf("Latency: %s", opts.Latency.summary())
+	}
+	if cfg.JSONPerFile != "" {
+		fmt.Printf("Plugin metadata written to %d JSON files in %s. Please check the log file for details.\n", len(pluginMetas), outputFile)
+	} else if cfg.OutputTemplate != nil && outputFile != "-" {
+		fmt.Printf("Plugin metadata rendered with %s to %s\n", cfg.Template, outputFile)
+	} else if !cfg.StatsOnly && cfg.OutputTemplate == nil {
+		fmt.Printf("Plugin metadata exported to %s. Please check the log file for details.\n", strings.ToUpper(cfg.Format))
+	}
+	if cfg.GroupBy != "" {
+		fmt.Printf("Plugin counts by %s written to %s\n", cfg.GroupBy, groupsFile)
+	}
+	for _, warning := range healthWarnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", warning)
+	}
+	if opts.Latency != nil {
+		fmt.Printf("Latency: %s\n", opts.Latency.summary())
+	}
+	if cfg.CIAnnotations {
+		writeAnnotations(os.Stdout, failures, healthWarnings)
+	}
+
+	if aborted {
+		if cfg.CIAnnotations {
+			writeAnnotation(os.Stdout, "error", "Run aborted", fmt.Sprintf("%d failures reached the -max-failures limit of %d after %d of %d URLs", failed, failureLimit, len(results), len(urls)))
+		}
+		fmt.Fprintf(os.Stderr, "Aborted after %d of %d URLs: %d failures reached the -max-failures limit of %d. Partial results were exported; see %s\n", len(results), len(urls), failed, failureLimit, errorsReportFile)
+		return 1
+	}
+
+	if incomplete > 0 && cfg.RequireMode == "fail" {
+		log.Printf("%d rows are missing required fields", incomplete)
+		if cfg.CIAnnotations {
+			writeAnnotation(os.Stdout, "error", "Required fields missing", fmt.Sprintf("%d rows are missing required fields (%s)", incomplete, strings.Join(cfg.RequireFields, ", ")))
+		}
+		fmt.Fprintf(os.Stderr, "%d rows are missing required fields (%s); see %s\n", incomplete, strings.Join(cfg.RequireFields, ", "), "scraper.log")
+		return 1
+	}
+	return 0
 }
 
-// scrapePluginMetaWithRetry attempts to scrape plugin metadata with retry logic
-func scrapePluginMetaWithRetry(url string, maxRetries int) (PluginMeta, error) {
-	var meta PluginMeta
-	var err error
+// quietLog suppresses the per-URL and per-field progress lines of scraper.log, set from -quiet-http
+var quietLog bool
 
-	for i := 0; i < maxRetries; i++ {
-		meta, err = scrapePluginMeta(url)
+// logVerbose logs a high-volume progress line unless -quiet-http is set. Errors, warnings and
+// summary lines use the log package directly so they are always kept
+func logVerbose(format string, v ...any) {
+	if !quietLog {
+		log.Output(2, fmt.Sprintf(format, v...))
+	}
+}
+
+// canonicalizeURLs canonicalizes every URL, keeping the original when it cannot be parsed
+func canonicalizeURLs(urls []string, locale string, enforceHTTPS bool) []string {
+	canonical := make([]string, len(urls))
+	for i, rawURL := range urls {
+		u, err := canonicalizeURL(rawURL, locale, enforceHTTPS)
+		if err != nil {
+			log.Printf("Warning: Could not canonicalize URL %q: %v", rawURL, err)
+			u = rawURL
+		} else if u != rawURL {
+			logVerbose("Canonicalized URL: %s -> %s", rawURL, u)
+		}
+		canonical[i] = u
+	}
+	return canonical
+}
+
+// windowURLs discards the first skip URLs, keeps every nth of the rest (n <= 1 keeps all)
+// and returns at most limit of those (0 means no limit)
+func windowURLs(urls []string, skip, every, limit int) []string {
+	if skip >= len(urls) {
+		return nil
+	}
+	urls = urls[skip:]
+	if every > 1 {
+		sampled := make([]string, 0, (len(urls)+every-1)/every)
+		for i := 0; i < len(urls); i += every {
+			sampled = append(sampled, urls[i])
+		}
+		urls = sampled
+	}
+	if limit > 0 && limit < len(urls) {
+		urls = urls[:limit]
+	}
+	return urls
+}
+
+// randomDelay returns a random wait time between lo and hi inclusive
+func randomDelay(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + time.Duration(rand.Int63n(int64(hi-lo)+1))
+}
+
+// criticalFields are the fields every plugin page has; a page parsed without them is a parse anomaly
+var criticalFields = []string{"Name", "Version"}
+
+// parseRetryBackoff is the first wait before retrying a parse anomaly with -retry-on-parse-error;
+// each further attempt doubles it
+const parseRetryBackoff = 2 * time.Second
+
+// scrapePluginMetaWithRetry attempts to scrape plugin metadata, retrying throttled requests up to retries times.
+// With opts.RetryOnParseError, pages parsed without the critical fields are retried as well, sharing the retries.
+// A 403 Forbidden is retried once with each of opts.UserAgents, outside the retries budget: switching
+// User-Agent advances agent but not attempt.
+// With opts.Requeue a throttled request isn't waited for but returned as a *requeueError
+func scrapePluginMetaWithRetry(url string, retries int, opts scrapeOptions) (PluginMeta, error) {
+	agent := 0
+	for attempt := opts.Attempt; ; {
+		opts.Throttle.wait()
+		started := time.Now()
+		meta, err := scrapePluginMeta(url, opts)
+		opts.Latency.record(time.Since(started))
+		if auditErr := opts.Audit.record(url, attempt+agent+1, started, err); auditErr != nil {
+			log.Printf("Warning: Failed to write audit record: %v", auditErr)
+		}
+		if err == nil && opts.RetryOnParseError && !isLocalURL(url) {
+			// A 200 whose page lacks the critical fields may have been truncated in transit
+			if missing := missingRequiredFields(meta, criticalFields); len(missing) > 0 {
+				if attempt < retries {
+					wait := parseRetryBackoff << attempt
+					log.Printf("Warning: %s parsed without %s, possibly a truncated page. Retrying after %v", url, strings.Join(missing, ", "), wait)
+					time.Sleep(wait)
+					attempt++
+					continue
+				}
+				log.Printf("Warning: %s still lacks %s after %d attempts, keeping the row", url, strings.Join(missing, ", "), attempt+1)
+			}
+		}
 		if err == nil {
 			return meta, nil
 		}
 
-		if strings.Contains(err.Error(), "429") {
-			retryAfter := time.Duration(30+rand.Intn(30)) * time.Second
-			log.Printf("429 error. Retrying after %v: %s", retryAfter, url)
-			time.Sleep(retryAfter)
-		} else {
+		// A 403 may come from bot detection rather than a real restriction, so try the next User-Agent
+		if isBlockedError(err) && agent < len(opts.UserAgents) && !isLocalURL(url) {
+			opts.UserAgent = opts.UserAgents[agent]
+			agent++
+			log.Printf("Warning: %s answered 403 Forbidden, retrying with User-Agent %d of %d", url, agent, len(opts.UserAgents))
+			continue
+		}
+
+		if errors.Is(err, errRedirectLoop) || errors.Is(err, errTooManyRedirects) || errors.Is(err, errRedirectScheme) || errors.Is(err, errRedirectHost) {
+			log.Printf("Redirect error is not transient, not retrying: %s", url)
 			return meta, err
 		}
+		if errors.Is(err, errNotHTTPS) {
+			log.Printf("Request outside https is rejected by -strict-security, not retrying: %s", url)
+			return meta, err
+		}
+
+		wait, retry := retryWait(err, attempt, opts.RetryAfterMax)
+		if !retry {
+			return meta, err
+		}
+		if attempt >= retries {
+			if retries == 0 {
+				return meta, err
+			}
+			return meta, fmt.Errorf("maximum retry count reached: %w", err)
+		}
+		if opts.Requeue {
+			log.Printf("%v. Requeued to retry after %v, continuing with other URLs: %s", err, wait, url)
+			return meta, &requeueError{Wait: wait, Attempts: attempt + 1, Err: err}
+		}
+		log.Printf("%v. Retrying after %v: %s", err, wait, url)
+		opts.Throttle.backoff(wait)
+		attempt++
 	}
+}
+
+// requeueError is returned with -requeue-throttled for a throttled request worth retrying after Wait
+type requeueError struct {
+	Wait time.Duration
+	// Attempts is the number of attempts made at the URL so far
+	Attempts int
+	Err      error
+}
+
+func (e *requeueError) Error() string {
+	return fmt.Sprintf("requeued for %v: %v", e.Wait, e.Err)
+}
+
+func (e *requeueError) Unwrap() error {
+	return e.Err
+}
+
+// httpStatusError is returned when a plugin page responds with a status other than 200 OK
+type httpStatusError struct {
+	StatusCode int
+	// Header is the response header, consulted for Retry-After
+	Header http.Header
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("invalid HTTP status: %d", e.StatusCode)
+}
+
+// errSlugMismatch is returned with -strict-slug when redirects end on a page with a different slug
+var errSlugMismatch = errors.New("slug mismatch")
+
+// HTTPStatus is the HTTP status code a plugin page responded with, 0 when no response was received
+type HTTPStatus int
 
-	return meta, fmt.Errorf("maximum retry count reached: %v", err)
+// String returns the status code, or an empty string when there was no response
+func (s HTTPStatus) String() string {
+	if s == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(s))
+}
+
+// errorCategory classifies a scrape error into a short, stable category for reporting
+func errorCategory(err error) string {
+	switch {
+	case errors.Is(err, errRedirectLoop):
+		return "redirect-loop"
+	case errors.Is(err, errTooManyRedirects):
+		return "too-many-redirects"
+	case errors.Is(err, errRedirectScheme):
+		return "redirect-scheme"
+	case errors.Is(err, errRedirectHost):
+		return "redirect-host"
+	case errors.Is(err, errMissingRequiredFields):
+		return "missing-fields"
+	case errors.Is(err, errSlugMismatch):
+		return "slug-mismatch"
+	case errors.Is(err, errNotHTTPS):
+		return "not-https"
+	case errors.Is(err, errPanic):
+		return "panic"
+	case strings.Contains(err.Error(), "429"):
+		return "rate-limited"
+	case strings.Contains(err.Error(), "invalid HTTP status"):
+		return "http-status"
+	default:
+		return "fetch-failed"
+	}
+}
+
+// scrapeOptions controls optional extraction behaviour of scrapePluginMeta
+type scrapeOptions struct {
+	// DumpMetaItems logs the raw text of every metadata <li> for diagnosing selector problems
+	DumpMetaItems bool
+	// NoDefaults leaves fields that could not be scraped empty instead of filling in their default tag
+	NoDefaults bool
+	// FAQ captures the FAQ section
+	FAQ bool
+	// DescriptionMax, if positive, captures the description as plain text of at most this many characters
+	DescriptionMax int
+	// NameFromTitle falls back to the document <title> when h1.plugin-title is missing
+	NameFromTitle bool
+	// LogConnections logs the negotiated protocol and whether the connection was reused
+	LogConnections bool
+	// Audit, if not nil, records every scrape attempt
+	Audit *auditLog
+	// ArchiveDir, if set, receives the raw HTML of every fetched page, gzip-compressed with ArchiveGzip
+	ArchiveDir  string
+	ArchiveGzip bool
+	// Latency, if not nil, collects the duration of every attempt
+	Latency *latencyRecorder
+	// RetryAfterMax caps the wait before retrying a throttled request
+	RetryAfterMax time.Duration
+	// Requeue hands a throttled URL back to the scheduler to retry later instead of pausing the pool.
+	// Attempt is the number of attempts made at the URL before, counted against the retries
+	Requeue bool
+	Attempt int
+	// Baseline, if not nil, holds the rows of the -baseline output by slug; only rows differing from it are exported
+	Baseline map[string]map[string]string
+	// Sources maps plugin slugs to the -input-glob file they were listed in
+	Sources map[string]string
+	// RetryOnParseError retries pages parsed without the critical fields
+	RetryOnParseError bool
+	// StrictSlug fails pages whose slug after redirects differs from the requested one instead of only flagging them
+	StrictSlug bool
+	// UserAgents are tried in turn when a page answers 403 Forbidden; UserAgent is the one of the current attempt
+	UserAgents []string
+	UserAgent  string
+	// Replay, if not nil, holds previously exported rows that are exported again instead of scraping anything
+	Replay []PluginMeta
+	// Conditional, if not nil, revalidates pages scraped by a previous run and reuses their rows when unchanged
+	Conditional *conditionalCache
+	// Throttle, if not nil, is shared by the workers so a throttled response pauses all of them
+	Throttle *throttleGate
+}
+
+// fingerprint identifies the options that change what parsePluginMeta extracts from a page, so a row
+// is only reused for a run extracting the same fields
+func (o scrapeOptions) fingerprint() string {
+	return fmt.Sprintf("faq=%t description-max=%d name-from-title=%t no-defaults=%t", o.FAQ, o.DescriptionMax, o.NameFromTitle, o.NoDefaults)
 }
 
 // scrapePluginMeta scrapes metadata from a single plugin page
-func scrapePluginMeta(url string) (PluginMeta, error) {
-	log.Printf("Starting scrape: %s", url)
+func scrapePluginMeta(url string, opts scrapeOptions) (PluginMeta, error) {
+	logVerbose("Starting scrape: %s", url)
 	start := time.Now()
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return PluginMeta{}, err
+	}
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+	if !isLocalURL(url) {
+		opts.Conditional.prepare(req, url)
+	}
+	var reused bool
+	if opts.LogConnections {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused },
+		}))
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		log.Printf("HTTP GET request failed: %s", err)
 		return PluginMeta{}, err
 	}
 	defer resp.Body.Close()
 
+	if opts.LogConnections {
+		log.Printf("Debug: %s protocol=%s connection-reused=%v", url, resp.Proto, reused)
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		if meta, ok := opts.Conditional.cached(url); ok {
+			logVerbose("Not modified, reusing the previous row: %s", url)
+			meta.FetchedAt = timestampNow()
+			return meta, nil
+		}
+	}
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("Invalid HTTP status: %d for %s", resp.StatusCode, url)
-		return PluginMeta{URL: url}, fmt.Errorf("invalid HTTP status: %d", resp.StatusCode)
+		return PluginMeta{URL: url, HTTPStatus: HTTPStatus(resp.StatusCode)}, &httpStatusError{StatusCode: resp.StatusCode, Header: resp.Header}
+	}
+
+	var body io.Reader = resp.Body
+	if isLocalURL(url) && strings.HasSuffix(url, ".gz") {
+		// Pages archived with -archive-gzip are read back compressed
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return PluginMeta{}, err
+		}
+		defer gz.Close()
+		body = gz
+	} else if opts.ArchiveDir != "" && !isLocalURL(url) && !isLocalURL(resp.Request.URL.String()) {
+		html, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return PluginMeta{}, err
+		}
+		if err := archivePage(html, url, opts.ArchiveDir, opts.ArchiveGzip); err != nil {
+			log.Printf("Warning: Failed to archive %s: %v", url, err)
+		}
+		body = bytes.NewReader(html)
+	}
+
+	meta, err := parsePluginMeta(body, url, opts)
+	if err != nil {
+		return PluginMeta{}, err
+	}
+	meta.FetchedAt = timestampNow()
+	meta.HTTPStatus = HTTPStatus(resp.StatusCode)
+	if _, ok := meta.installCount(); ok && meta.InstallsAsOf.IsZero() {
+		// The figure is at least as recent as the page it was read from
+		meta.InstallsAsOf, meta.InstallsAsOfApprox = meta.FetchedAt, true
 	}
+	meta.FinalURL = resp.Request.URL.String()
+
+	// A redirect may land on another plugin (a renamed slug) or a catch-all page, whose metadata must
+	// not be recorded under the requested slug unnoticed
+	if final := pluginSlug(resp.Request.URL.String()); !isLocalURL(url) && final != meta.Slug {
+		log.Printf("Warning: %s redirected to %s, whose slug %q differs from the requested %q", url, resp.Request.URL, final, meta.Slug)
+		meta.FinalSlug = final
+		if opts.StrictSlug {
+			return PluginMeta{URL: url, Slug: meta.Slug, FinalSlug: final, HTTPStatus: meta.HTTPStatus}, fmt.Errorf("%w: requested %q, got %q", errSlugMismatch, meta.Slug, final)
+		}
+	}
+	if !isLocalURL(url) {
+		opts.Conditional.store(url, resp.Header, meta)
+	}
+
+	logVerbose("Completed scrape: %s (duration: %v)", url, time.Since(start))
+
+	return meta, nil
+}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+// parsePluginMeta extracts the metadata of the plugin page at url from its HTML.
+// It does no I/O besides reading r, so extraction can be exercised with plain HTML strings
+func parsePluginMeta(r io.Reader, url string, opts scrapeOptions) (PluginMeta, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		log.Printf("Failed to parse HTML: %s", err)
 		return PluginMeta{}, err
 	}
 
-	meta := PluginMeta{URL: url}
-	meta.Name = strings.TrimSpace(doc.Find("h1.plugin-title").Text())
+	meta := PluginMeta{URL: url, Slug: pluginSlug(url)}
+	meta.Name = strings.TrimSpace(doc.Find(pluginTitleSelector).Text())
+	if meta.Name == "" && opts.NameFromTitle {
+		meta.Name = nameFromTitle(doc.Find("title").First().Text())
+		if meta.Name != "" {
+			meta.setSource("Name", sourceTitleFallback)
+			logVerbose("Name taken from document title: %s", url)
+		}
+	}
 
-	doc.Find("div.entry-meta > div.widget.plugin-meta > ul > li").Each(func(i int, s *goquery.Selection) {
+	doc.Find(metaItemsSelector).Each(func(i int, s *goquery.Selection) {
 		text := s.Text()
+		if opts.DumpMetaItems {
+			log.Printf("Debug: %s meta item %d: %q", url, i, strings.Join(strings.Fields(text), " "))
+		}
 		switch {
 		case strings.Contains(text, "Version"):
 			meta.Version = extractStrong(s)
@@ -132,6 +1022,13 @@ func scrapePluginMeta(url string) (PluginMeta, error) {
 			meta.LastUpdated = extractStrong(s)
 		case strings.Contains(text, "Active installations"):
 			meta.Installs = extractStrong(s)
+			if n, ok := preciseInstallCount(s, meta.Installs); ok {
+				count := InstallCount(n)
+				meta.InstallCount = &count
+			}
+			if t, ok := installsAsOf(s); ok {
+				meta.InstallsAsOf = Timestamp{t}
+			}
 		case strings.Contains(text, "WordPress version"):
 			meta.WPVersion = extractStrong(s)
 		case strings.Contains(text, "Tested up to"):
@@ -139,30 +1036,66 @@ func scrapePluginMeta(url string) (PluginMeta, error) {
 		case strings.Contains(text, "PHP version"):
 			meta.PHPVersion = extractStrong(s)
 		case strings.Contains(text, "Languages"):
-			meta.Languages = strings.TrimSpace(s.Find("button").Text())
+			meta.Languages, meta.LanguageCount = extractLanguages(s)
 		case strings.Contains(text, "Tags"):
 			meta.Tags = strings.TrimSpace(s.Find(".tags").Text())
 		}
 	})
 
-	setDefaultValues(&meta)
+	if n, ok := meta.installCount(); ok {
+		meta.InstallTier = installTier(n)
+	}
+	meta.Compat = newCompatRange(meta.WPVersion, meta.TestedUpTo)
+	meta.IconURL = extractIconURL(doc)
+	meta.BannerURL = extractBannerURL(doc)
+	meta.DonateURL = extractDonateURL(doc)
+	meta.HomepageURL = extractHomepageURL(doc)
+	meta.StableTag = extractStableTag(doc)
+	if stableTagMismatch(meta.StableTag, meta.Version) {
+		meta.StableTagMismatch = true
+		log.Printf("Warning: %s: stable tag %s differs from the displayed version %s", url, meta.StableTag, meta.Version)
+	}
+	if meta.StableTag == "" {
+		meta.StableTag = meta.Version
+	}
+	meta.PreviousVersions = extractPreviousVersions(doc)
+	meta.CompatibilityVotes = extractCompatibilityVotes(doc)
+	meta.SupportURL, meta.SupportStats = extractSupport(doc, url)
+	meta.IsFreemium, meta.FreemiumEvidence = detectFreemium(doc)
+	meta.UntestedWarningText = extractUntestedWarning(doc)
+	meta.UntestedWarning = meta.UntestedWarningText != ""
+	if opts.FAQ {
+		meta.FAQ = extractFAQ(doc)
+	}
+	if opts.DescriptionMax > 0 {
+		meta.Description = extractDescription(doc, opts.DescriptionMax)
+	}
 
-	log.Printf("Completed scrape: %s (duration: %v)", url, time.Since(start))
+	if !opts.NoDefaults {
+		setDefaultValues(&meta)
+	}
 
 	return meta, nil
 }
 
 // setDefaultValues sets default values for empty fields in PluginMeta
 func setDefaultValues(meta *PluginMeta) {
-	t := reflect.TypeOf(*meta)
-	v := reflect.ValueOf(meta).Elem()
+	fillDefaults(reflect.ValueOf(meta).Elem())
+}
+
+// fillDefaults sets every empty string field of the struct v that has a default tag to that default.
+// Fields of other types are skipped, so a default tag on a numeric or time field can't cause a panic
+func fillDefaults(v reflect.Value) {
+	t := v.Type()
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if defaultVal, ok := field.Tag.Lookup("default"); ok {
-			if v.Field(i).String() == "" {
-				v.Field(i).SetString(defaultVal)
-				log.Printf("Set default value: %s=%s", field.Name, defaultVal)
-			}
+		defaultVal, ok := field.Tag.Lookup("default")
+		if !ok || field.Type.Kind() != reflect.String || !v.Field(i).CanSet() {
+			continue
+		}
+		if v.Field(i).String() == "" {
+			v.Field(i).SetString(defaultVal)
+			logVerbose("Set default value: %s=%s", field.Name, defaultVal)
 		}
 	}
 }
@@ -172,70 +1105,390 @@ func extractStrong(s *goquery.Selection) string {
 	return strings.TrimSpace(s.Find("strong").Text())
 }
 
+// nameFromTitle extracts the plugin name from a document title such as
+// "Akismet Anti-spam: Spam Protection – WordPress plugin | WordPress.org"
+func nameFromTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if i := strings.LastIndex(title, " | "); i >= 0 {
+		title = title[:i]
+	}
+	for _, sep := range []string{" – ", " — ", " - "} {
+		if i := strings.LastIndex(title, sep); i >= 0 && strings.Contains(strings.ToLower(title[i:]), "wordpress") {
+			title = title[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(title)
+}
+
+// extractIconURL extracts the plugin icon URL, preferring the highest-resolution srcset candidate
+func extractIconURL(doc *goquery.Document) string {
+	img := doc.Find("img.plugin-icon").First()
+	if srcset, ok := img.Attr("srcset"); ok {
+		if url := highestResSrcset(srcset); url != "" {
+			return url
+		}
+	}
+	src, _ := img.Attr("src")
+	return strings.TrimSpace(src)
+}
+
+// highestResSrcset returns the candidate URL with the largest descriptor (e.g. 2x or 256w) in a srcset attribute
+func highestResSrcset(srcset string) string {
+	var best string
+	var bestScale float64
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		scale := 1.0
+		if len(fields) > 1 {
+			if v, err := strconv.ParseFloat(strings.TrimRight(fields[1], "xw"), 64); err == nil {
+				scale = v
+			}
+		}
+		if best == "" || scale > bestScale {
+			best, bestScale = fields[0], scale
+		}
+	}
+	return best
+}
+
+// cssURLPattern matches url(...) references in inline CSS
+var cssURLPattern = regexp.MustCompile(`url\(\s*['"]?([^'")]+)['"]?\s*\)`)
+
+// extractBannerURL extracts the plugin banner URL from the banner's inline styles.
+// The high-resolution banner is declared last (inside a min-resolution media query), so the last match wins
+func extractBannerURL(doc *goquery.Document) string {
+	var css strings.Builder
+	doc.Find("style").Each(func(i int, s *goquery.Selection) {
+		if strings.Contains(s.Text(), "plugin-banner") {
+			css.WriteString(s.Text())
+		}
+	})
+	if style, ok := doc.Find(".plugin-banner").Attr("style"); ok {
+		css.WriteString(style)
+	}
+
+	var banner string
+	for _, m := range cssURLPattern.FindAllStringSubmatch(css.String(), -1) {
+		if strings.Contains(m[1], "banner") {
+			banner = m[1]
+		}
+	}
+	return banner
+}
+
+// inlineElements are the elements whose text runs on with the surrounding text. Any other element
+// separates its text from its neighbours, so "<li>One</li><li>Two</li>" becomes "One Two"
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "code": true, "em": true, "i": true, "kbd": true, "mark": true,
+	"q": true, "s": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true, "u": true,
+}
+
+// extractDescription extracts the description section as plain text with normalized whitespace,
+// truncated to maxLen characters. The section heading is left out
+func extractDescription(doc *goquery.Document, maxLen int) string {
+	section := doc.Find(descriptionSelector).First().Clone()
+	section.Find("#description-header").Remove()
+
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style"):
+			return
+		}
+		block := n.Type == html.ElementNode && !inlineElements[n.Data]
+		if block {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			b.WriteByte(' ')
+		}
+	}
+	for _, n := range section.Nodes {
+		walk(n)
+	}
+
+	text := strings.Join(strings.Fields(b.String()), " ")
+	if runes := []rune(text); len(runes) > maxLen {
+		text = strings.TrimSpace(string(runes[:maxLen-1])) + "…"
+	}
+	return text
+}
+
+// untestedWarningPattern matches the notice shown for plugins untested with recent WordPress releases,
+// e.g. "This plugin hasn't been tested with the latest 3 major releases of WordPress"
+var untestedWarningPattern = regexp.MustCompile(`(?i)hasn['’]?t been tested with the latest`)
+
+// extractUntestedWarning returns the text of the plugin notice warning that the plugin hasn't been tested
+// with recent WordPress releases, or an empty string when the page shows no such notice
+func extractUntestedWarning(doc *goquery.Document) string {
+	var warning string
+	doc.Find(".plugin-notice").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if text := strings.Join(strings.Fields(s.Text()), " "); untestedWarningPattern.MatchString(text) {
+			warning = text
+		}
+		return warning == ""
+	})
+	return warning
+}
+
+// homepageLinkPattern matches the text of a link to the plugin's homepage
+var homepageLinkPattern = regexp.MustCompile(`(?i)^(?:plugin )?(?:homepage|website)\b`)
+
+// extractHomepageURL extracts the plugin's external homepage: a "Plugin Homepage" link when the page has
+// one, otherwise the author link of the byline, which wordpress.org points at the homepage declared by the
+// plugin. Only absolute http(s) URLs are returned
+func extractHomepageURL(doc *goquery.Document) string {
+	var href string
+	doc.Find(".plugin-header a[href], .entry-meta a[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if s.HasClass("plugin-homepage") || homepageLinkPattern.MatchString(strings.TrimSpace(s.Text())) {
+			href, _ = s.Attr("href")
+		}
+		return href == ""
+	})
+	if href == "" {
+		href, _ = doc.Find(".plugin-header .byline a[href]").First().Attr("href")
+	}
+	href = strings.TrimSpace(href)
+	if !isAbsoluteHTTPURL(href) {
+		return ""
+	}
+	return href
+}
+
+// extractDonateURL extracts the donate link from the plugin sidebar. Only absolute http(s) URLs are
+// accepted; anything else yields an empty string
+func extractDonateURL(doc *goquery.Document) string {
+	href, _ := doc.Find(".plugin-donate a[href]").First().Attr("href")
+	href = strings.TrimSpace(href)
+	if !isAbsoluteHTTPURL(href) {
+		return ""
+	}
+	return href
+}
+
+// compatWorksPattern and compatBrokenPattern match the vote counts of the compatibility widget,
+// e.g. "12 people say it works" and "1 person says it's broken"
+var (
+	compatWorksPattern  = regexp.MustCompile(`(\d[\d,]*) (?:people say|person says) it works`)
+	compatBrokenPattern = regexp.MustCompile(`(\d[\d,]*) (?:people say|person says) it(?:['’]s| is) broken`)
+)
+
+// extractCompatibilityVotes extracts the works/broken votes of the compatibility widget, returning nil
+// when the page doesn't show it
+func extractCompatibilityVotes(doc *goquery.Document) *CompatibilityVotes {
+	text := strings.Join(strings.Fields(doc.Find(".compatibility, #plugin-compatibility").First().Text()), " ")
+	works := compatWorksPattern.FindStringSubmatch(text)
+	broken := compatBrokenPattern.FindStringSubmatch(text)
+	if works == nil && broken == nil {
+		return nil
+	}
+	votes := &CompatibilityVotes{}
+	if works != nil {
+		votes.Works, _ = strconv.Atoi(strings.ReplaceAll(works[1], ",", ""))
+	}
+	if broken != nil {
+		votes.Broken, _ = strconv.Atoi(strings.ReplaceAll(broken[1], ",", ""))
+	}
+	return votes
+}
+
+// maxPreviousVersions caps the versions captured from the previous versions dropdown,
+// since long-lived plugins list hundreds of releases
+const maxPreviousVersions = 100
+
+// extractPreviousVersions extracts the versions listed in the previous versions dropdown, skipping
+// entries that aren't a version number such as "Development Version"
+func extractPreviousVersions(doc *goquery.Document) []string {
+	versions := []string{}
+	doc.Find("select.previous-versions option").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		version := strings.TrimSpace(s.Text())
+		if normalizeVersion(version) == version && version != "" {
+			versions = append(versions, version)
+		}
+		return len(versions) < maxPreviousVersions
+	})
+	return versions
+}
+
 // readURLsFromCSV reads plugin URLs from a CSV file
 func readURLsFromCSV(filename string) ([]string, error) {
+	urls, _, err := readInputCSV(filename, nil, true)
+	return urls, err
+}
+
+// readInputCSV reads plugin URLs from the first column of a CSV file, along with the values of the
+// named passthrough columns for each row, keyed by plugin slug. With strict, every row must have as
+// many fields as the header; otherwise ragged rows are accepted and missing passthrough values are empty.
+// Rows whose URL is malformed or longer than maxURLLength are skipped with a warning
+func readInputCSV(filename string, passthrough []string, strict bool) ([]string, map[string]map[string]string, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	if !strict {
+		reader.FieldsPerRecord = -1
+	}
 	
 	// ヘッダー行を読み飛ばす
-	if _, err := reader.Read(); err != nil {
-		return nil, err
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([]int, len(passthrough))
+	for i, name := range passthrough {
+		columns[i] = slices.IndexFunc(header, func(h string) bool { return strings.EqualFold(strings.TrimSpace(h), name) })
+		if columns[i] < 0 {
+			return nil, nil, fmt.Errorf("passthrough column %q not found in %s", name, filename)
+		}
 	}
 
 	var urls []string
+	extras := make(map[string]map[string]string)
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, err
+			// A csv.ParseError names the offending line
+			return nil, nil, fmt.Errorf("%s: %w", filename, err)
 		}
 		if len(record) > 0 {
+			if err := checkInputURL(record[0]); err != nil {
+				line, _ := reader.FieldPos(0)
+				log.Printf("Warning: Skipping line %d of %s: %v", line, filename, err)
+				continue
+			}
 			urls = append(urls, record[0])
+			if len(passthrough) > 0 {
+				values := make(map[string]string, len(passthrough))
+				for i, name := range passthrough {
+					if columns[i] < len(record) {
+						values[name] = record[columns[i]]
+					}
+				}
+				extras[pluginSlug(record[0])] = values
+			}
 		}
 	}
-	return urls, nil
+	return urls, extras, nil
+}
+
+// outputColumns are the exported columns of PluginMeta, derived from its csv struct tags
+var outputColumns = pluginColumns(reflect.TypeOf(PluginMeta{}), nil)
+
+// outputHeaders are the column names of the exported results, in the order produced by pluginRow
+var outputHeaders = columnNames(outputColumns)
+
+// passthroughColumns are the input columns copied to the output after the scraped columns
+var passthroughColumns []string
+
+// headerRow returns the output column names, including any passthrough columns, as renamed by -rename
+func headerRow() []string {
+	return renameColumns(append(slices.Clone(outputHeaders), passthroughColumns...))
+}
+
+// installsColumn is the index of the Active Installations column in outputHeaders
+var installsColumn = slices.Index(outputHeaders, "Active Installations")
+
+// setOutputColumns replaces the exported columns, e.g. to leave out an opt-in column that wasn't asked for
+func setOutputColumns(columns []column) {
+	outputColumns = columns
+	outputHeaders = columnNames(columns)
+	installsColumn = slices.Index(outputHeaders, "Active Installations")
+}
+
+// pluginRow returns the exported column values of a plugin, in the order of headerRow
+func pluginRow(item PluginMeta) []string {
+	v := reflect.ValueOf(item)
+	row := make([]string, 0, len(outputColumns)+len(passthroughColumns))
+	for _, col := range outputColumns {
+		row = append(row, formatCell(v.FieldByIndex(col.Index)))
+	}
+	for _, name := range passthroughColumns {
+		row = append(row, item.Passthrough[name])
+	}
+	return row
 }
 
 // exportToCSV exports the scraped plugin metadata to a CSV file
 func exportToCSV(data []PluginMeta, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+	return exportWith(newCSVOutputWriter)(data, filename)
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// exportToJSON exports the scraped plugin metadata to a JSON file as an array of objects
+func exportToJSON(data []PluginMeta, filename string) error {
+	return exportWith(newJSONOutputWriter)(data, filename)
+}
 
-	headers := []string{"URL", "Name", "Version", "Last Updated", "Active Installations", "WordPress Version", "Tested Up To", "PHP Version", "Languages", "Tags"}
-	if err := writer.Write(headers); err != nil {
-		return err
-	}
+// exporters maps each supported -format to the function writing that format
+var exporters = map[string]func(data []PluginMeta, filename string) error{
+	"csv":     exportToCSV,
+	"json":    exportToJSON,
+	"xlsx":    exportToXLSX,
+	"html":    exportToHTML,
+	"parquet": exportToParquet,
+}
 
-	for _, item := range data {
-		row := []string{
-			item.URL,
-			item.Name,
-			item.Version,
-			item.LastUpdated,
-			item.Installs,
-			item.WPVersion,
-			item.TestedUpTo,
-			item.PHPVersion,
-			item.Languages,
-			item.Tags,
-		}
-		if err := writer.Write(row); err != nil {
+// exportToSplitFiles exports the scraped plugin metadata into numbered files of at most splitSize rows each
+func exportToSplitFiles(data []PluginMeta, filename string, splitSize int, export func([]PluginMeta, string) error) error {
+	for i, chunk := 0, 1; i < len(data); i, chunk = i+splitSize, chunk+1 {
+		end := min(i+splitSize, len(data))
+		chunkFile := chunkFilename(filename, chunk)
+		if err := export(data[i:end], chunkFile); err != nil {
 			return err
 		}
+		log.Printf("Wrote %d rows to %s", end-i, chunkFile)
 	}
-
 	return nil
 }
+
+// chunkFilename returns the numbered file name for a chunk, e.g. results.csv -> results_0001.csv
+// and results.csv.gz -> results_0001.csv.gz
+func chunkFilename(filename string, chunk int) string {
+	return withNameSuffix(filename, fmt.Sprintf("_%04d", chunk))
+}
+
+// snapshotFilename returns the file name of a -watch snapshot started at t, e.g. results.csv -> results_20240102T150405Z.csv
+func snapshotFilename(filename string, t time.Time) string {
+	return withNameSuffix(filename, "_"+t.UTC().Format("20060102T150405Z"))
+}
+
+// withNameSuffix inserts suffix before the extension of filename, keeping a .gz extension last
+func withNameSuffix(filename, suffix string) string {
+	base, gz := strings.CutSuffix(filename, ".gz")
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext) + suffix + ext
+	if gz {
+		name += ".gz"
+	}
+	return name
+}
+
+// writeFileAtomic writes an output file (or object store URL, see openSink) so that readers never
+// observe a partially written file
+func writeFileAtomic(filename string, write func(w io.Writer) error) error {
+	sink, err := openSink(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(sink); err != nil {
+		sink.abort()
+		return err
+	}
+	return sink.commit()
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// setDefaultValues logs every default it sets
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSetDefaultValuesFillsEmptyFields(t *testing.T) {
+	meta := PluginMeta{URL: "https://wordpress.org/plugins/akismet/"}
+	setDefaultValues(&meta)
+
+	want := map[string]string{
+		"URL":         "https://wordpress.org/plugins/akismet/",
+		"Slug":        "N/A",
+		"Name":        "Unknown",
+		"Version":     "0.0.0",
+		"LastUpdated": "N/A",
+		"Installs":    "N/A",
+		"InstallTier": "N/A",
+		"WPVersion":   "N/A",
+		"TestedUpTo":  "N/A",
+		"PHPVersion":  "N/A",
+		"Languages":   "N/A",
+		"Tags":        "N/A",
+	}
+	v := reflect.ValueOf(meta)
+	for field, value := range want {
+		if got := v.FieldByName(field).String(); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+}
+
+func TestSetDefaultValuesKeepsScrapedValues(t *testing.T) {
+	meta := PluginMeta{Name: "Akismet", Version: "5.3.3", Installs: "5+ million"}
+	setDefaultValues(&meta)
+
+	if meta.Name != "Akismet" || meta.Version != "5.3.3" || meta.Installs != "5+ million" {
+		t.Errorf("scraped values were overwritten: %+v", meta)
+	}
+}
+
+func TestSetDefaultValuesSkipsFieldsWithoutDefaultTag(t *testing.T) {
+	meta := PluginMeta{}
+	setDefaultValues(&meta)
+
+	if meta.IconURL != "" || meta.BannerURL != "" || meta.DonateURL != "" || !meta.FetchedAt.IsZero() {
+		t.Errorf("fields without a default tag were filled: %+v", meta)
+	}
+	if meta.Compat != (CompatRange{}) || meta.PreviousVersions != nil || meta.VersionStats != nil || meta.Passthrough != nil {
+		t.Errorf("non-string fields were modified: %+v", meta)
+	}
+}
+
+func TestFillDefaultsSkipsNonStringFields(t *testing.T) {
+	var s struct {
+		Name     string   `default:"Unknown"`
+		Count    int      `default:"5"`
+		Rating   float64  `default:"1.5"`
+		Tags     []string `default:"none"`
+		internal string   `default:"hidden"`
+	}
+	fillDefaults(reflect.ValueOf(&s).Elem())
+
+	if s.Name != "Unknown" {
+		t.Errorf("Name = %q, want %q", s.Name, "Unknown")
+	}
+	if s.Count != 0 || s.Rating != 0 || s.Tags != nil {
+		t.Errorf("non-string fields were modified: %+v", s)
+	}
+	if s.internal != "" {
+		t.Errorf("unexported field was modified: %q", s.internal)
+	}
+}
+
+// roundTripperFunc serves requests with a function, standing in for the network
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestScrapePluginMetaWithInjectedTransport(t *testing.T) {
+	page, err := os.ReadFile("testdata/plugin_page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/html; charset=UTF-8"}},
+			Body:       io.NopCloser(bytes.NewReader(page)),
+			Request:    req,
+		}, nil
+	})
+	client, err := newHTTPClient(Config{MaxRedirects: 10, Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := httpClient
+	httpClient = client
+	t.Cleanup(func() { httpClient = previous })
+
+	meta, err := scrapePluginMeta("https://wordpress.org/plugins/sample-forms/", scrapeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Slug != "sample-forms" || meta.Name == "Unknown" || meta.Version != "2.30.0" {
+		t.Errorf("canned page scraped incompletely: %+v", meta)
+	}
+}
+
+func TestUserAgentRotationKeepsRetries(t *testing.T) {
+	page, err := os.ReadFile("testdata/plugin_page.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two 403s use up both User-Agents, then a 429 needs the single retry
+	statuses := []int{http.StatusForbidden, http.StatusForbidden, http.StatusTooManyRequests, http.StatusOK}
+	var requests int
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		status := statuses[min(requests, len(statuses)-1)]
+		requests++
+		body := []byte{}
+		if status == http.StatusOK {
+			body = page
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     http.Header{"Content-Type": {"text/html; charset=UTF-8"}, "Retry-After": {"0"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	client, err := newHTTPClient(Config{MaxRedirects: 10, Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := httpClient
+	httpClient = client
+	t.Cleanup(func() { httpClient = previous })
+
+	opts := scrapeOptions{UserAgents: []string{"agent-a", "agent-b"}}
+	meta, err := scrapePluginMetaWithRetry("https://wordpress.org/plugins/sample-forms/", 1, opts)
+	if err != nil {
+		t.Fatalf("retry budget used up by User-Agent rotation: %v", err)
+	}
+	if meta.Version != "2.30.0" || requests != 4 {
+		t.Errorf("got version %q after %d requests, want 2.30.0 after 4", meta.Version, requests)
+	}
+}
+
+func TestRedirectToFileIsRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/hostname", http.StatusFound)
+	}))
+	defer server.Close()
+
+	// Even a -from-dir run, which serves file:// URLs, must not follow a page to a local file
+	for _, fromDir := range []string{"", "testdata"} {
+		client, err := newHTTPClient(Config{MaxRedirects: 10, FromDir: fromDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatalf("FromDir %q: redirect to file:// was followed", fromDir)
+		}
+		if !errors.Is(err, errRedirectScheme) {
+			t.Errorf("FromDir %q: err = %v, want errRedirectScheme", fromDir, err)
+		}
+	}
+}
+
+func TestResolveRenamesRejectsCollisions(t *testing.T) {
+	for _, pairs := range [][]string{
+		{"Version=Slug"},
+		{"Version=slug"},
+		{"Version=plugin_version", "Name=plugin_version"},
+		{"team=URL"},
+	} {
+		if _, err := resolveRenames(pairs, []string{"team"}); err == nil {
+			t.Errorf("resolveRenames(%q) accepted a colliding target", pairs)
+		}
+	}
+
+	for _, pairs := range [][]string{
+		{"Version=plugin_version", "Name=plugin_name"},
+		{"Version=Name", "Name=Version"},
+		{"Name=Name"},
+	} {
+		if _, err := resolveRenames(pairs, []string{"team"}); err != nil {
+			t.Errorf("resolveRenames(%q): %v", pairs, err)
+		}
+	}
+}
+
+func TestPluginKeyIgnoresURLSpelling(t *testing.T) {
+	want := pluginKey(PluginMeta{URL: "https://wordpress.org/plugins/akismet/"})
+	for _, rawURL := range []string{
+		"http://wordpress.org/plugins/akismet",
+		"https://ja.wordpress.org/plugins/akismet/",
+		"https://WordPress.org/plugins/Akismet/",
+		" https://www.wordpress.org/plugins/akismet/?ref=x ",
+	} {
+		if got := pluginKey(PluginMeta{URL: rawURL}); got != want {
+			t.Errorf("pluginKey(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+
+	if a, b := urlKey("https://example.com/"), urlKey("http://EXAMPLE.com"); a != b {
+		t.Errorf("urlKey of a URL without slug differs by spelling: %q, %q", a, b)
+	}
+}
+
+func TestPluginSlugOfLocalPageInPluginsDirectory(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		"file:///home/me/plugins/akismet.html":           "akismet",
+		"file:///data/plugins/saved/akismet.html.gz":     "akismet",
+		"file:///data/plugins/saved/classic-editor.html": "classic-editor",
+	} {
+		if got := pluginSlug(rawURL); got != want {
+			t.Errorf("pluginSlug(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
+
+func TestLoadCookieFileKeepsEmptyValues(t *testing.T) {
+	filename := t.TempDir() + "/cookies.txt"
+	content := "# Netscape HTTP Cookie File\r\n" +
+		"wordpress.org\tFALSE\t/\tTRUE\t0\tsession\tabc\r\n" +
+		"#HttpOnly_wordpress.org\tFALSE\t/\tTRUE\t0\tconsent\t\r\n"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jar, err := newCookieJar(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cookies := jar.Cookies(&url.URL{Scheme: "https", Host: "wordpress.org", Path: "/"})
+	values := make(map[string]string)
+	for _, c := range cookies {
+		values[c.Name] = c.Value
+	}
+	if v, ok := values["consent"]; !ok || v != "" || values["session"] != "abc" {
+		t.Errorf("cookies = %v, want session=abc and an empty consent", values)
+	}
+}
+
+// parquetSplitFields are the JSON keys whose values the Parquet output writes to columns of other names
+var parquetSplitFields = map[string][]string{
+	"install_count":       {"installs"},
+	"compat":              {"wp_min_version", "wp_max_version"},
+	"compatibility_votes": {"compat_works", "compat_broken"},
+	"support_stats":       {"support_resolved", "support_total"},
+}
+
+// TestParquetRowCoversPluginMeta guards against a PluginMeta field being added without a Parquet column
+func TestParquetRowCoversPluginMeta(t *testing.T) {
+	columns := make(map[string]bool)
+	rowType := reflect.TypeOf(parquetRow{})
+	for i := 0; i < rowType.NumField(); i++ {
+		name, _, _ := strings.Cut(rowType.Field(i).Tag.Get("parquet"), ",")
+		columns[name] = true
+	}
+
+	for _, key := range jsonKeys {
+		names, split := parquetSplitFields[key]
+		if !split {
+			names = []string{key}
+		}
+		for _, name := range names {
+			if !columns[name] {
+				t.Errorf("JSON key %q has no Parquet column %q", key, name)
+			}
+		}
+	}
+}
+
+// benchmarkParsePluginMeta parses the saved plugin page in testdata with opts b.N times.
+// Run with go test -bench ParsePluginMeta -benchmem to compare time and allocations per parse
+func benchmarkParsePluginMeta(b *testing.B, opts scrapeOptions) {
+	page, err := os.ReadFile("testdata/plugin_page.html")
+	if err != nil {
+		b.Fatal(err)
+	}
+	const url = "https://wordpress.org/plugins/sample-forms/"
+
+	// Guard against timing a fixture whose selectors no longer match
+	meta, err := parsePluginMeta(bytes.NewReader(page), url, opts)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if meta.Name == "Unknown" || meta.Version != "2.30.0" || len(meta.PreviousVersions) == 0 {
+		b.Fatalf("fixture parsed incompletely: %+v", meta)
+	}
+
+	b.SetBytes(int64(len(page)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parsePluginMeta(bytes.NewReader(page), url, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParsePluginMeta(b *testing.B) {
+	benchmarkParsePluginMeta(b, scrapeOptions{})
+}
+
+func BenchmarkParsePluginMetaAllFields(b *testing.B) {
+	benchmarkParsePluginMeta(b, scrapeOptions{FAQ: true, DescriptionMax: 2000})
+}
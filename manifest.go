@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Manifest lists the files written by a run with their checksums, so consumers can verify
+// that a published dataset arrived intact
+type Manifest struct {
+	StartedAt   time.Time      `json:"started_at"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file listed in the manifest. Name is relative to the manifest's directory
+type ManifestFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+	// Rows is the number of data rows, omitted when it isn't known (e.g. -only-failed merges)
+	Rows *int `json:"rows,omitempty"`
+}
+
+// manifestEntry is a written file to list in the manifest, with its row count if known
+type manifestEntry struct {
+	path string
+	rows *int
+}
+
+// manifestFilename returns the manifest file name for an output file, e.g. results.csv -> results.manifest.json
+func manifestFilename(outputFile string) string {
+	return sidecarFilename(outputFile, ".manifest.json")
+}
+
+// outputManifestEntries returns the output files written for rows, one per chunk with -split-size
+func outputManifestEntries(outputFile string, rows, splitSize int) []manifestEntry {
+	if splitSize <= 0 {
+		return []manifestEntry{{path: outputFile, rows: &rows}}
+	}
+	var entries []manifestEntry
+	for i, chunk := 0, 1; i < rows; i, chunk = i+splitSize, chunk+1 {
+		n := min(splitSize, rows-i)
+		entries = append(entries, manifestEntry{path: chunkFilename(outputFile, chunk), rows: &n})
+	}
+	return entries
+}
+
+// writeManifest hashes the files of entries and writes the manifest to filename
+func writeManifest(entries []manifestEntry, startedAt time.Time, filename string) error {
+	manifest := Manifest{StartedAt: startedAt, GeneratedAt: time.Now(), Files: []ManifestFile{}}
+	for _, entry := range entries {
+		file, err := hashFile(entry.path)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(filepath.Dir(filename), entry.path); err == nil {
+			file.Name = filepath.ToSlash(rel)
+		}
+		file.Rows = entry.rows
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(manifest)
+	})
+}
+
+// hashFile returns the size and SHA-256 checksum of a file
+func hashFile(path string) (ManifestFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+	return ManifestFile{Name: path, SHA256: hex.EncodeToString(h.Sum(nil)), Bytes: n}, nil
+}
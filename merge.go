@@ -0,0 +1,125 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// mergeResultFiles combines result CSVs (e.g. from runs split with -skip/-limit) into output. Columns
+// are aligned by header name, so files with different column sets can be merged, and each plugin slug
+// is kept once: the row with the most recent Fetched At wins, or the row from the later file on a tie.
+// It returns the number of rows written
+func mergeResultFiles(inputs []string, output string) (int, error) {
+	var headers []string
+	var rows []map[string]string
+	index := make(map[string]int)
+
+	for _, filename := range inputs {
+		records, err := readCSVFile(filename)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", filename, err)
+		}
+		if len(records) == 0 {
+			continue
+		}
+
+		header := records[0]
+		for _, name := range header {
+			if !slices.Contains(headers, name) {
+				headers = append(headers, name)
+			}
+		}
+
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(header))
+			for i, value := range record {
+				if i < len(header) {
+					row[header[i]] = value
+				}
+			}
+
+			key := mergeKey(row)
+			i, seen := index[key]
+			switch {
+			case key == "" || !seen:
+				index[key] = len(rows)
+				rows = append(rows, row)
+			case !fetchedBefore(row, rows[i]):
+				rows[i] = row
+			}
+		}
+	}
+	if headers == nil {
+		return 0, fmt.Errorf("no rows to merge")
+	}
+
+	err := writeFileAtomic(output, func(w io.Writer) error {
+		writer := newResultsCSVWriter(w)
+		if err := writer.Write(headers); err != nil {
+			return err
+		}
+		record := make([]string, len(headers))
+		for _, row := range rows {
+			for i, name := range headers {
+				record[i] = row[name]
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	return len(rows), err
+}
+
+// mergeKey returns the plugin slug identifying a result row, falling back to the key of its URL (see urlKey)
+func mergeKey(row map[string]string) string {
+	if slug := row["Slug"]; slug != "" && slug != "N/A" {
+		return slug
+	}
+	return urlKey(row["URL"])
+}
+
+// fetchedBefore reports whether row a was scraped before row b. A row without a valid
+// Fetched At is treated as older than one with it
+func fetchedBefore(a, b map[string]string) bool {
+	ta, errA := time.Parse(time.RFC3339, a["Fetched At"])
+	tb, errB := time.Parse(time.RFC3339, b["Fetched At"])
+	switch {
+	case errA != nil:
+		return errB == nil
+	case errB != nil:
+		return false
+	default:
+		return ta.Before(tb)
+	}
+}
+
+// readCSVFile reads all records of a CSV file, decompressing it when the name ends in .gz
+func readCSVFile(filename string) ([][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	reader := newResultsCSVReader(r)
+	reader.FieldsPerRecord = -1
+	return reader.ReadAll()
+}
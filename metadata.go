@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// version is the scraper version, set at build time with -ldflags "-X main.version=v1.2.3"
+var version = ""
+
+// RunMetadata records how a dataset was produced, so a run can be reconstructed later
+type RunMetadata struct {
+	Version    string            `json:"version"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Input      string            `json:"input"`
+	Output     string            `json:"output"`
+	Format     string            `json:"format"`
+	URLs       int               `json:"urls"`
+	Rows       int               `json:"rows"`
+	Failures   int               `json:"failures"`
+	Options    map[string]string `json:"options"`
+}
+
+// scraperVersion returns the build version, falling back to the module version or VCS revision
+func scraperVersion() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return "devel"
+}
+
+// resolvedOptions returns the effective value of every command-line flag, including defaults
+func resolvedOptions() map[string]string {
+	options := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		options[f.Name] = f.Value.String()
+	})
+	return options
+}
+
+// runMetadataFilename returns the metadata file name for an output file, e.g. results.csv -> results.run.json
+func runMetadataFilename(outputFile string) string {
+	return sidecarFilename(outputFile, ".run.json")
+}
+
+// sidecarFilename replaces the extension of an output file (including a .gz) with ext
+func sidecarFilename(outputFile, ext string) string {
+	outputFile = strings.TrimSuffix(outputFile, ".gz")
+	if i := strings.LastIndex(outputFile, "."); i > 0 {
+		outputFile = outputFile[:i]
+	}
+	return outputFile + ext
+}
+
+// writeRunMetadata writes the run metadata as indented JSON
+func writeRunMetadata(meta RunMetadata, filename string) error {
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(meta)
+	})
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OutputWriter receives scraped plugin metadata one row at a time. Close finishes the output;
+// after a failed Write, Close discards the partial output and returns the error
+type OutputWriter interface {
+	Write(meta PluginMeta) error
+	Close() error
+}
+
+// writeAll writes every plugin to w and closes it
+func writeAll(w OutputWriter, data []PluginMeta) error {
+	for _, item := range data {
+		if err := w.Write(item); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// exportWith returns an exporter that writes a file through the OutputWriter built by newWriter
+func exportWith(newWriter func(filename string) (OutputWriter, error)) func([]PluginMeta, string) error {
+	return func(data []PluginMeta, filename string) error {
+		w, err := newWriter(filename)
+		if err != nil {
+			return err
+		}
+		return writeAll(w, data)
+	}
+}
+
+// outputSink is the destination of an output file. Nothing written becomes visible to readers
+// until commit; abort discards it
+type outputSink interface {
+	io.Writer
+	commit() error
+	abort()
+}
+
+// objectStores maps URL schemes such as s3 to the constructor of a sink writing an object to that store.
+// Stores register themselves from files built with a build tag (-tags s3, -tags gcs), so the cloud SDKs
+// are only compiled in when needed
+var objectStores = map[string]func(u *url.URL) (outputSink, error){}
+
+// objectStoreScheme returns the scheme of target when it is an object store URL such as s3://bucket/key
+func objectStoreScheme(target string) (string, bool) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	return scheme, ok && scheme != "" && rest != "" && !strings.ContainsAny(scheme, `/\.`)
+}
+
+// checkOutputTarget returns an error when target is an object store URL this build cannot write to
+func checkOutputTarget(target string) error {
+	scheme, remote := objectStoreScheme(target)
+	if _, ok := objectStores[scheme]; remote && !ok {
+		return fmt.Errorf("%s:// output is not supported by this build (rebuild with -tags s3 or -tags gcs)", scheme)
+	}
+	return nil
+}
+
+// openSink opens the sink for an output file name or object store URL. The output is gzip-compressed
+// when target ends in .gz
+func openSink(target string) (outputSink, error) {
+	var sink outputSink
+	if scheme, remote := objectStoreScheme(target); remote {
+		if err := checkOutputTarget(target); err != nil {
+			return nil, err
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		if sink, err = objectStores[scheme](u); err != nil {
+			return nil, err
+		}
+	} else {
+		f, err := createAtomic(target)
+		if err != nil {
+			return nil, err
+		}
+		sink = f
+	}
+
+	if strings.HasSuffix(target, ".gz") {
+		sink = &gzipSink{Writer: gzip.NewWriter(sink), inner: sink}
+	}
+	return sink, nil
+}
+
+// gzipSink compresses the data written to another sink
+type gzipSink struct {
+	*gzip.Writer
+	inner outputSink
+}
+
+// commit flushes the compressed data and commits the underlying sink
+func (s *gzipSink) commit() error {
+	if err := s.Writer.Close(); err != nil {
+		s.inner.abort()
+		return err
+	}
+	return s.inner.commit()
+}
+
+// abort discards the underlying sink
+func (s *gzipSink) abort() {
+	s.inner.abort()
+}
+
+// atomicFile is a temporary file in the directory of filename that is renamed into place on commit,
+// so readers never observe a partially written file
+type atomicFile struct {
+	*os.File
+	filename string
+}
+
+// createAtomic creates the temporary file for filename
+func createAtomic(filename string) (*atomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: tmp, filename: filename}, nil
+}
+
+// commit closes the temporary file and renames it to filename
+func (f *atomicFile) commit() error {
+	if err := f.Chmod(0644); err != nil {
+		f.abort()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.filename); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// abort closes and removes the temporary file
+func (f *atomicFile) abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// csvOutputWriter writes plugins as CSV rows under a header row
+type csvOutputWriter struct {
+	sink   outputSink
+	writer csvRecordWriter
+	err    error
+}
+
+// newCSVOutputWriter creates a CSV file and writes its header row
+func newCSVOutputWriter(filename string) (OutputWriter, error) {
+	sink, err := openSink(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := &csvOutputWriter{sink: sink, writer: newResultsCSVWriter(sink)}
+	w.err = w.writer.Write(headerRow())
+	return w, nil
+}
+
+// Write appends the row of a plugin
+func (w *csvOutputWriter) Write(meta PluginMeta) error {
+	if w.err == nil {
+		w.err = w.writer.Write(pluginRow(meta))
+	}
+	return w.err
+}
+
+// Close flushes the rows and moves the file into place
+func (w *csvOutputWriter) Close() error {
+	if w.err == nil {
+		w.writer.Flush()
+		w.err = w.writer.Error()
+	}
+	if w.err != nil {
+		w.sink.abort()
+		return w.err
+	}
+	return w.sink.commit()
+}
+
+// jsonOutputWriter writes plugins as an indented JSON array of objects
+type jsonOutputWriter struct {
+	sink  outputSink
+	buf   *bufio.Writer
+	count int
+	err   error
+}
+
+// newJSONOutputWriter creates a JSON file
+func newJSONOutputWriter(filename string) (OutputWriter, error) {
+	sink, err := openSink(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonOutputWriter{sink: sink, buf: bufio.NewWriter(sink)}, nil
+}
+
+// Write appends a plugin object to the array
+func (w *jsonOutputWriter) Write(meta PluginMeta) error {
+	if w.err != nil {
+		return w.err
+	}
+	data, err := marshalPlugin(meta, "  ")
+	if err != nil {
+		w.err = err
+		return err
+	}
+	sep := ",\n  "
+	if w.count == 0 {
+		sep = "[\n  "
+	}
+	w.count++
+	if _, err := w.buf.WriteString(sep); err != nil {
+		w.err = err
+		return err
+	}
+	_, w.err = w.buf.Write(data)
+	return w.err
+}
+
+// Close terminates the array and moves the file into place
+func (w *jsonOutputWriter) Close() error {
+	if w.err == nil {
+		end := "\n]\n"
+		if w.count == 0 {
+			end = "[]\n"
+		}
+		if _, w.err = w.buf.WriteString(end); w.err == nil {
+			w.err = w.buf.Flush()
+		}
+	}
+	if w.err != nil {
+		w.sink.abort()
+		return w.err
+	}
+	return w.sink.commit()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetRow is the typed, columnar representation of a PluginMeta. Numeric and date fields
+// are real numbers and timestamps (null when they couldn't be parsed) so the file loads
+// directly into pandas or DuckDB
+type parquetRow struct {
+	URL              string             `parquet:"url"`
+	Slug             string             `parquet:"slug"`
+	Name             string             `parquet:"name"`
+	Version          string             `parquet:"version"`
+	LastUpdated      int64              `parquet:"last_updated,optional,timestamp(millisecond)"`
+	Installs         *int64             `parquet:"installs,optional"`
+	InstallTier      string             `parquet:"install_tier"`
+	CanonicalURL     string             `parquet:"canonical_url"`
+	FinalURL         string             `parquet:"final_url"`
+	HomepageURL      string             `parquet:"homepage_url"`
+	InstallsLog10    *float64           `parquet:"installs_log10,optional"`
+	InstallsAsOf     int64              `parquet:"installs_as_of,optional,timestamp(millisecond)"`
+	AsOfApproximate  bool               `parquet:"installs_as_of_approximate"`
+	WPVersion        string             `parquet:"wp_version"`
+	TestedUpTo       string             `parquet:"tested_up_to"`
+	StableTag        string             `parquet:"stable_tag"`
+	StableMismatch   bool               `parquet:"stable_tag_mismatch"`
+	WPMinVersion     string             `parquet:"wp_min_version"`
+	WPMaxVersion     string             `parquet:"wp_max_version"`
+	PHPVersion       string             `parquet:"php_version"`
+	Languages        string             `parquet:"languages"`
+	LanguageCount    *int64             `parquet:"language_count,optional"`
+	Tags             string             `parquet:"tags"`
+	IconURL          string             `parquet:"icon_url"`
+	BannerURL        string             `parquet:"banner_url"`
+	DonateURL        string             `parquet:"donate_url"`
+	FetchedAt        int64              `parquet:"fetched_at,optional,timestamp(millisecond)"`
+	HTTPStatus       int32              `parquet:"http_status,optional"`
+	FinalSlug        string             `parquet:"final_slug"`
+	UntestedWarning  bool               `parquet:"untested_warning"`
+	UntestedText     string             `parquet:"untested_warning_text"`
+	Description      string             `parquet:"description"`
+	IsFreemium       bool               `parquet:"is_freemium"`
+	FreemiumEvidence string             `parquet:"freemium_evidence"`
+	CompatWorks      *int64             `parquet:"compat_works,optional"`
+	CompatBroken     *int64             `parquet:"compat_broken,optional"`
+	SupportURL       string             `parquet:"support_url"`
+	SupportResolved  *int64             `parquet:"support_resolved,optional"`
+	SupportTotal     *int64             `parquet:"support_total,optional"`
+	PreviousVersions []string           `parquet:"previous_versions,list"`
+	FAQ              []FAQItem          `parquet:"faq,list"`
+	FirstReleased    int64              `parquet:"first_released,optional,timestamp(millisecond)"`
+	LatestReleased   int64              `parquet:"latest_released,optional,timestamp(millisecond)"`
+	ReleaseCount     *int64             `parquet:"release_count,optional"`
+	SourceFile       string             `parquet:"source_file"`
+	VersionStats     map[string]float64 `parquet:"version_stats"`
+	ChangeType       string             `parquet:"change_type"`
+	ChangedFields    []string           `parquet:"changed_fields,list"`
+	Passthrough      map[string]string  `parquet:"passthrough"`
+}
+
+// newParquetRow converts a PluginMeta into its typed parquet row.
+// Relative "Last updated" values such as "2 weeks ago" are resolved against now
+func newParquetRow(item PluginMeta, now time.Time) parquetRow {
+	row := parquetRow{
+		URL:              item.URL,
+		Slug:             item.Slug,
+		Name:             item.Name,
+		Version:          item.Version,
+		CanonicalURL:     item.CanonicalURL,
+		FinalURL:         item.FinalURL,
+		HomepageURL:      item.HomepageURL,
+		InstallTier:      item.InstallTier,
+		WPVersion:        item.WPVersion,
+		TestedUpTo:       item.TestedUpTo,
+		StableTag:        item.StableTag,
+		StableMismatch:   item.StableTagMismatch,
+		AsOfApproximate:  item.InstallsAsOfApprox,
+		WPMinVersion:     item.Compat.Min,
+		WPMaxVersion:     item.Compat.Max,
+		PHPVersion:       item.PHPVersion,
+		Languages:        item.Languages,
+		Tags:             item.Tags,
+		IconURL:          item.IconURL,
+		BannerURL:        item.BannerURL,
+		DonateURL:        item.DonateURL,
+		HTTPStatus:       int32(item.HTTPStatus),
+		FinalSlug:        item.FinalSlug,
+		UntestedWarning:  item.UntestedWarning,
+		UntestedText:     item.UntestedWarningText,
+		Description:      item.Description,
+		IsFreemium:       item.IsFreemium,
+		FreemiumEvidence: item.FreemiumEvidence,
+		SupportURL:       item.SupportURL,
+		PreviousVersions: item.PreviousVersions,
+		FAQ:              item.FAQ,
+		VersionStats:     item.VersionStats,
+		SourceFile:       item.SourceFile,
+		ChangeType:       item.ChangeType,
+		ChangedFields:    item.ChangedFields,
+		Passthrough:      item.Passthrough,
+	}
+	// Optional non-pointer columns are written as null when zero
+	if t, ok := parseLastUpdated(item.LastUpdated, now); ok {
+		row.LastUpdated = t.UnixMilli()
+	}
+	if !item.FetchedAt.IsZero() {
+		row.FetchedAt = item.FetchedAt.UnixMilli()
+	}
+	if !item.InstallsAsOf.IsZero() {
+		row.InstallsAsOf = item.InstallsAsOf.UnixMilli()
+	}
+	if v := item.CompatibilityVotes; v != nil {
+		works, broken := int64(v.Works), int64(v.Broken)
+		row.CompatWorks, row.CompatBroken = &works, &broken
+	}
+	if s := item.SupportStats; s != nil {
+		resolved, total := int64(s.Resolved), int64(s.Total)
+		row.SupportResolved, row.SupportTotal = &resolved, &total
+	}
+	if d := item.FirstReleased; d != nil {
+		row.FirstReleased = d.UnixMilli()
+	}
+	if d := item.LatestReleased; d != nil {
+		row.LatestReleased = d.UnixMilli()
+	}
+	if item.ReleaseCount > 0 {
+		n := int64(item.ReleaseCount)
+		row.ReleaseCount = &n
+	}
+	if item.LanguageCount > 0 {
+		n := int64(item.LanguageCount)
+		row.LanguageCount = &n
+	}
+	if l := item.InstallsLog10; l != nil {
+		v := float64(*l)
+		row.InstallsLog10 = &v
+	}
+	if n, ok := item.installCount(); ok {
+		row.Installs = &n
+	}
+	return row
+}
+
+// exportToParquet exports the scraped plugin metadata to a Parquet file with typed columns
+func exportToParquet(data []PluginMeta, filename string) error {
+	now := time.Now()
+	rows := make([]parquetRow, len(data))
+	for i, item := range data {
+		rows[i] = newParquetRow(item, now)
+	}
+
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		writer := parquet.NewGenericWriter[parquetRow](w)
+		if _, err := writer.Write(rows); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseInstallCount converts an active installations string such as "10,000+", "5+ million"
+// or "Fewer than 10" into the lower bound it represents
+func parseInstallCount(s string) (int64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, false
+	}
+	if strings.HasPrefix(s, "fewer than") || strings.HasPrefix(s, "less than") {
+		return 0, true
+	}
+
+	multiplier := int64(1)
+	if strings.HasSuffix(s, "million") {
+		multiplier = 1000000
+		s = strings.TrimSuffix(s, "million")
+	}
+	s = strings.NewReplacer(",", "", "+", "", " ", "").Replace(s)
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// LogScale is a base-10 logarithm
+type LogScale float64
+
+// String returns the logarithm with two decimals, or an empty string when there is none
+func (l *LogScale) String() string {
+	if l == nil {
+		return ""
+	}
+	return strconv.FormatFloat(float64(*l), 'f', 2, 64)
+}
+
+// InstallCount is a number of active installations
+type InstallCount int64
+
+// String returns the number, or an empty string when there is none
+func (c *InstallCount) String() string {
+	if c == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*c), 10)
+}
+
+// installCount returns the most precise number of active installations known for a plugin: the exact
+// InstallCount when the page had one, otherwise the lower bound of the displayed Installs text
+func (m PluginMeta) installCount() (int64, bool) {
+	if m.InstallCount != nil {
+		return int64(*m.InstallCount), true
+	}
+	return parseInstallCount(m.Installs)
+}
+
+// installCountPattern matches an install count in an attribute value, e.g. "5,234,567" or "5+ million"
+var installCountPattern = regexp.MustCompile(`(?i)\d[\d,]*\+?(?:\s*million)?`)
+
+// installCountAttributes are the attributes of the active installations element that may carry a more
+// precise figure than its text
+var installCountAttributes = []string{"title", "data-count", "data-installs", "data-value"}
+
+// preciseInstallCount looks for a more precise install count than the displayed text in the title or data
+// attributes of the active installations <li> and its <strong>. A value is only accepted when it is at
+// least the lower bound of the displayed text, which it refines
+func preciseInstallCount(s *goquery.Selection, displayed string) (int64, bool) {
+	bound, ok := parseInstallCount(displayed)
+	if !ok {
+		return 0, false
+	}
+	for _, sel := range []*goquery.Selection{s.Find("strong").First(), s} {
+		for _, attr := range installCountAttributes {
+			value, exists := sel.Attr(attr)
+			if !exists {
+				continue
+			}
+			if n, ok := parseInstallCount(installCountPattern.FindString(value)); ok && n > bound {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// installsAsOfAttributes are the attributes of the active installations element that may carry the date
+// its figure was computed
+var installsAsOfAttributes = []string{"datetime", "data-as-of", "data-date", "data-updated"}
+
+// installsAsOfLayouts are the accepted layouts of an installations as-of date
+var installsAsOfLayouts = []string{time.RFC3339, releaseDateLayout}
+
+// installsAsOf looks for the date the active installations figure was computed in a <time> element or the
+// date attributes of the active installations <li> and its <strong>. Neither the page nor the API
+// usually gives one, so ok is mostly false
+func installsAsOf(s *goquery.Selection) (time.Time, bool) {
+	for _, sel := range []*goquery.Selection{s.Find("time[datetime]").First(), s.Find("strong").First(), s} {
+		for _, attr := range installsAsOfAttributes {
+			value, exists := sel.Attr(attr)
+			if !exists {
+				continue
+			}
+			for _, layout := range installsAsOfLayouts {
+				if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// installsLog10 returns the base-10 logarithm of a plugin's number of active installations for plotting
+// adoption on a log scale. "Fewer than 10" (a lower bound of 0) maps to 0 like a single install; unknown
+// counts give nil
+func installsLog10(meta PluginMeta) *LogScale {
+	n, ok := meta.installCount()
+	if !ok {
+		return nil
+	}
+	l := LogScale(math.Log10(float64(max(n, 1))))
+	return &l
+}
+
+// installTier maps an install count onto the canonical tier label wordpress.org uses for it,
+// rounding down to one significant digit so "5,000,000+", "5+ million" and 5234567 all become "5+ million"
+func installTier(n int64) string {
+	if n < 10 {
+		return "Fewer than 10"
+	}
+
+	magnitude := int64(1)
+	for n/magnitude >= 10 {
+		magnitude *= 10
+	}
+	n = n / magnitude * magnitude
+
+	if n >= 1000000 {
+		return fmt.Sprintf("%d+ million", n/1000000)
+	}
+	return formatThousands(n) + "+"
+}
+
+// formatThousands formats n with comma thousands separators, e.g. 10000 -> "10,000"
+func formatThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// relativeUnits maps the units used in relative "Last updated" values to their approximate duration
+var relativeUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// lastUpdatedLayouts are the absolute date formats accepted for "Last updated"
+var lastUpdatedLayouts = []string{"January 2, 2006", "2006-01-02", "2006-01-02 3:04pm MST", time.RFC3339}
+
+// parseLastUpdated converts a "Last updated" value into a time. Relative values such as
+// "2 weeks ago" or "1 day ago" are resolved against now; absolute dates are parsed as-is
+func parseLastUpdated(s string, now time.Time) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range lastUpdatedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) != 3 || fields[2] != "ago" {
+		return time.Time{}, false
+	}
+	n := 1
+	if fields[0] != "a" && fields[0] != "an" {
+		var err error
+		if n, err = strconv.Atoi(fields[0]); err != nil {
+			return time.Time{}, false
+		}
+	}
+	unit, ok := relativeUnits[strings.TrimSuffix(fields[1], "s")]
+	if !ok {
+		return time.Time{}, false
+	}
+	return now.Add(-time.Duration(n) * unit), true
+}
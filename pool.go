@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+// urlResult is the outcome of processing a single URL
+type urlResult struct {
+	URL  string
+	Meta PluginMeta
+	// Err is the scrape error, or the missing-fields error for incomplete rows
+	Err error
+	// Incomplete is set when the row lacks a -require-fields field
+	Incomplete bool
+	// Keep reports whether the row belongs in the output
+	Keep bool
+	// Overloaded is set when the scrape error suggests the target is struggling, even when the
+	// status was recorded with -record-status
+	Overloaded bool
+	Took       time.Duration
+
+	// RetryAt is set with -requeue-throttled when the URL was throttled and is to be scraped again,
+	// not before then, after Attempts attempts
+	RetryAt  time.Time
+	Attempts int
+}
+
+// errPanic is wrapped by the error of a URL whose processing panicked, e.g. in an extractor on a malformed page
+var errPanic = errors.New("panic")
+
+// processURL scrapes a single URL and applies the per-row options. A panic while processing the URL is
+// recovered and recorded as its failure, with the panic value and stack, so the rest of the run goes on
+func processURL(url string, cfg Config, opts scrapeOptions) (r urlResult) {
+	logVerbose("Processing URL: %s", url)
+	started := time.Now()
+	defer func() {
+		if v := recover(); v != nil {
+			err := fmt.Errorf("%w: %v\n%s", errPanic, v, debug.Stack())
+			log.Printf("Warning: Error processing %s (%s): %v", url, errorCategory(err), err)
+			r = urlResult{URL: url, Meta: PluginMeta{URL: url, CanonicalURL: canonicalPluginURL(pluginSlug(url))}, Err: err, Keep: !cfg.OnlyFailed, Took: time.Since(started)}
+		}
+	}()
+
+	meta, err := scrapePluginMetaWithRetry(url, cfg.Retries, opts)
+	var requeue *requeueError
+	if errors.As(err, &requeue) {
+		return urlResult{URL: url, Err: err, Overloaded: true, Took: time.Since(started), RetryAt: time.Now().Add(requeue.Wait), Attempts: requeue.Attempts}
+	}
+	// Failed rows are kept in the output unless we are re-running failures,
+	// where only newly successful rows are merged
+	r = urlResult{URL: url, Meta: meta, Err: err, Keep: true, Overloaded: isOverloadError(err)}
+	var statusErr *httpStatusError
+	if cfg.RecordStatus && errors.As(err, &statusErr) {
+		// The status is recorded as the outcome of the row rather than reported as a failure
+		log.Printf("Recorded HTTP status %d for %s", statusErr.StatusCode, url)
+		r.Meta = PluginMeta{URL: url, Slug: pluginSlug(url), HTTPStatus: HTTPStatus(statusErr.StatusCode)}
+		if !opts.NoDefaults {
+			setDefaultValues(&r.Meta)
+		}
+		r.Err = nil
+	} else if err != nil {
+		log.Printf("Warning: Error processing %s (%s): %v", url, errorCategory(err), err)
+		// Keep the row keyed by its URL so a later -only-failed run can replace it
+		r.Meta.URL = url
+		r.Keep = !cfg.OnlyFailed
+	} else {
+		if cfg.InstallsLog10 {
+			r.Meta.InstallsLog10 = installsLog10(r.Meta)
+		}
+		if cfg.AdvancedStats {
+			r.Meta.VersionStats, err = fetchVersionStats(pluginSlug(url))
+			if err != nil {
+				log.Printf("Warning: Failed to fetch version stats for %s: %v", url, err)
+			}
+		}
+		if cfg.ReleaseDates {
+			history, err := fetchReleaseHistory(pluginSlug(url))
+			if err != nil {
+				log.Printf("Warning: Failed to fetch the release history for %s: %v", url, err)
+			}
+			r.Meta.FirstReleased, r.Meta.LatestReleased, r.Meta.ReleaseCount = history.FirstReleased, history.LatestReleased, history.Releases
+		}
+		if err := checkRequiredFields(r.Meta, cfg.RequireFields); err != nil {
+			log.Printf("Warning: %s: %v", url, err)
+			r.Incomplete = true
+			// In report mode incomplete rows are moved to the errors report
+			if cfg.RequireMode == "report" {
+				r.Err = err
+				r.Keep = false
+			}
+		}
+	}
+
+	// Every row, failed ones included, gets the join key
+	r.Meta.CanonicalURL = canonicalPluginURL(pluginSlug(url))
+	r.Took = time.Since(started)
+	logVerbose("Completed processing URL: %s", url)
+	return r
+}
+
+// scrapeAll processes urls with cfg.Workers workers and returns the results in input order.
+// onResult, if not nil, is called from a single goroutine as each URL completes.
+// In -workers auto mode an adaptive limiter decides how many of the workers may scrape at once, and
+// with -max-concurrent-per-host URLs are dispatched so that no host has more than that many in flight.
+// With -throttle-on-error-rate the delay between URLs grows while too many of the recent URLs fail.
+// With -requeue-throttled a throttled URL is handed out again once its retry time has come, and the
+// workers carry on with the other URLs meanwhile.
+// When ctx is cancelled no further URLs are dispatched and the URLs in flight are given up to
+// cfg.ShutdownGrace to finish; only the URLs processed by then are returned
+func scrapeAll(ctx context.Context, urls []string, cfg Config, opts scrapeOptions, onResult func(urlResult)) []urlResult {
+	workers := cfg.Workers
+	var limiter *adaptiveLimiter
+	if cfg.WorkersAuto {
+		workers = cfg.MaxWorkers
+		limiter = newAdaptiveLimiter(cfg.MaxWorkers)
+	}
+	workers = max(1, min(workers, len(urls)))
+	opts.Throttle = newThrottleGate(limiter)
+	hosts := newHostLimiter(cfg.MaxPerHost)
+	errorRate := newErrorRateController(cfg.ErrorRateThreshold, cfg.ErrorRateRecover, cfg.ErrorRateWindow)
+
+	results := make([]urlResult, len(urls))
+	jobs := make(chan int)
+	// done is buffered so workers still running after the grace period never block
+	done := make(chan int, len(urls))
+	// returned tells the dispatcher a URL is no longer in flight; a URL with RetryAt set is to be requeued.
+	// attempts counts the attempts made at each requeued URL, written by the dispatcher before handing it out
+	returned := make(chan int)
+	attempts := make([]int, len(urls))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if limiter != nil {
+					limiter.acquire()
+				}
+				jobOpts := opts
+				jobOpts.Attempt = attempts[i]
+				results[i] = processURL(urls[i], cfg, jobOpts)
+				hosts.release(urlHost(urls[i]))
+				if limiter != nil {
+					limiter.release(results[i].Took, results[i].Overloaded)
+				}
+				errorRate.record(isSoftFailure(results[i]))
+				if results[i].RetryAt.IsZero() {
+					done <- i
+				}
+				select {
+				case returned <- i:
+				case <-ctx.Done():
+				}
+
+				if !isLocalURL(urls[i]) {
+					select {
+					case <-time.After(errorRate.delay(randomDelay(cfg.DelayMin, cfg.DelayMax))):
+					case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		pending := newHostQueues(urls)
+		// delayed holds the requeued URLs until their RetryAt
+		var delayed []int
+		inFlight := 0
+	dispatch:
+		for !pending.empty() || len(delayed) > 0 || inFlight > 0 {
+			var wake <-chan time.Time
+			if len(delayed) > 0 {
+				now := time.Now()
+				var next time.Time
+				delayed = slices.DeleteFunc(delayed, func(i int) bool {
+					if !results[i].RetryAt.After(now) {
+						pending.push(i, urlHost(urls[i]))
+						return true
+					}
+					if next.IsZero() || results[i].RetryAt.Before(next) {
+						next = results[i].RetryAt
+					}
+					return false
+				})
+				if !next.IsZero() {
+					wake = time.After(time.Until(next))
+				}
+			}
+
+			// send stays nil, disabling its case, while every host with pending URLs is at its
+			// -max-concurrent-per-host limit
+			var send chan<- int
+			i, host, ok := pending.next(hosts)
+			if ok {
+				send = jobs
+			}
+			select {
+			case send <- i:
+				// Only the dispatcher takes slots, so the one found free is still free
+				hosts.acquire(host)
+				pending.pop(host)
+				inFlight++
+			case j := <-returned:
+				inFlight--
+				if !results[j].RetryAt.IsZero() {
+					attempts[j] = results[j].Attempts
+					delayed = append(delayed, j)
+				}
+			case <-hosts.freed:
+			case <-wake:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	processed := make([]bool, len(urls))
+	shutdown := ctx.Done()
+	var grace <-chan time.Time
+collect:
+	for {
+		select {
+		case i, ok := <-done:
+			if !ok {
+				break collect
+			}
+			processed[i] = true
+			if onResult != nil {
+				onResult(results[i])
+			}
+		case <-shutdown:
+			log.Printf("Stopping: waiting up to %v for the URLs in flight to finish", cfg.ShutdownGrace)
+			shutdown = nil
+			grace = time.After(cfg.ShutdownGrace)
+		case <-grace:
+			log.Printf("Warning: Shutdown grace period of %v expired, dropping the URLs still in flight", cfg.ShutdownGrace)
+			break collect
+		}
+	}
+
+	// Workers may still be writing the results of dropped URLs, so the results are copied
+	var completed []urlResult
+	for i, r := range results {
+		if processed[i] {
+			completed = append(completed, r)
+		}
+	}
+	return completed
+}
+
+// isOverloadError reports whether err suggests the target is struggling (rate limiting, server
+// errors or network failures) rather than a problem with the individual page
+func isOverloadError(err error) bool {
+	if err == nil || errors.Is(err, errMissingRequiredFields) {
+		return false
+	}
+	switch errorCategory(err) {
+	case "rate-limited", "fetch-failed":
+		return true
+	case "http-status":
+		var statusErr *httpStatusError
+		return errors.As(err, &statusErr) && statusErr.StatusCode >= 500
+	default:
+		return false
+	}
+}
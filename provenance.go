@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Field sources of the -json-provenance output
+const (
+	// sourceScrape is the plugin page, or a value derived from it
+	sourceScrape = "scrape"
+	// sourceAPI is the WordPress.org API (-advanced-stats, -release-dates)
+	sourceAPI = "api"
+	// sourceInput is the input file: the URL and what is copied from its row
+	sourceInput = "input"
+	// sourceDefault marks a field that couldn't be scraped and holds its default value
+	sourceDefault = "default"
+	// sourceTitleFallback marks a name taken from the document <title> (-name-from-title)
+	sourceTitleFallback = "title-fallback"
+)
+
+// jsonProvenance is set by -json-provenance: JSON objects then hold every field as {"value": ..., "source": ...}
+var jsonProvenance bool
+
+// provenanceValue is a field of the -json-provenance output
+type provenanceValue struct {
+	Value  json.RawMessage `json:"value"`
+	Source string          `json:"source"`
+}
+
+// setSource records that field (a PluginMeta field name) was taken from source instead of the page itself
+func (m *PluginMeta) setSource(field, source string) {
+	if m.Provenance == nil {
+		m.Provenance = make(map[string]string)
+	}
+	m.Provenance[field] = source
+}
+
+// fieldSources returns the source of every top-level field of meta by JSON key: the source recorded
+// during extraction, otherwise sourceDefault for a field holding its default value, otherwise the
+// field's source tag, and sourceScrape for fields without one
+func fieldSources(meta PluginMeta) map[string]string {
+	v := reflect.ValueOf(meta)
+	t := v.Type()
+	sources := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := jsonKey(field)
+		if key == "-" {
+			continue
+		}
+		source := sourceScrape
+		if tagged, ok := field.Tag.Lookup("source"); ok {
+			source = tagged
+		}
+		if defaultVal, ok := field.Tag.Lookup("default"); ok && v.Field(i).Kind() == reflect.String && v.Field(i).String() == defaultVal {
+			source = sourceDefault
+		}
+		if recorded, ok := meta.Provenance[field.Name]; ok {
+			source = recorded
+		}
+		sources[key] = source
+	}
+	return sources
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// pluginInfoURL is the plugin information endpoint of the WordPress.org API, asked for the versions map
+const pluginInfoURL = "https://api.wordpress.org/plugins/info/1.2/?action=plugin_information&request[fields][versions]=1&request[slug]="
+
+// releaseDateLayout is the layout of the release dates in the output
+const releaseDateLayout = "2006-01-02"
+
+// pluginInfoDateLayout is the layout of the API's last_updated value, e.g. "2024-06-05 1:26pm GMT"
+const pluginInfoDateLayout = "2006-01-02 3:04pm MST"
+
+// ReleaseHistory is what the API tells about a plugin's releases. The versions map only links each version
+// to its download, so the dates of individual versions aren't available; the first release date is when
+// the plugin was added to the directory and the latest is its last update
+type ReleaseHistory struct {
+	FirstReleased  *Date
+	LatestReleased *Date
+	Releases       int
+}
+
+// pluginInfo is the part of the plugin information response used for the release history
+type pluginInfo struct {
+	Added       string `json:"added"`
+	LastUpdated string `json:"last_updated"`
+	// Versions is a version -> download URL object, or [] when the plugin has none (PHP's empty array)
+	Versions json.RawMessage `json:"versions"`
+	// Error is set instead for unknown plugins, e.g. "Plugin not found."
+	Error string `json:"error"`
+}
+
+// fetchReleaseHistory fetches the release history of the plugin from the WordPress.org API
+func fetchReleaseHistory(slug string) (ReleaseHistory, error) {
+	if slug == "" {
+		return ReleaseHistory{}, fmt.Errorf("cannot fetch the release history without a plugin slug")
+	}
+
+	resp, err := httpClient.Get(pluginInfoURL + url.QueryEscape(slug))
+	if err != nil {
+		return ReleaseHistory{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ReleaseHistory{}, fmt.Errorf("invalid HTTP status: %d", resp.StatusCode)
+	}
+	return decodeReleaseHistory(resp.Body)
+}
+
+// decodeReleaseHistory decodes a plugin information response into the release history. Dates are
+// normalized to YYYY-MM-DD, and the development version (trunk) doesn't count as a release
+func decodeReleaseHistory(r io.Reader) (ReleaseHistory, error) {
+	var info pluginInfo
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return ReleaseHistory{}, fmt.Errorf("failed to decode plugin information: %w", err)
+	}
+	if info.Error != "" {
+		return ReleaseHistory{}, fmt.Errorf("plugin information: %s", info.Error)
+	}
+
+	var history ReleaseHistory
+	if t, err := time.Parse(releaseDateLayout, info.Added); err == nil {
+		history.FirstReleased = &Date{t}
+	}
+	if t, err := time.Parse(pluginInfoDateLayout, info.LastUpdated); err == nil {
+		history.LatestReleased = &Date{t.UTC().Truncate(24 * time.Hour)}
+	}
+	var versions map[string]string
+	if bytes.HasPrefix(bytes.TrimSpace(info.Versions), []byte("{")) {
+		if err := json.Unmarshal(info.Versions, &versions); err != nil {
+			return ReleaseHistory{}, fmt.Errorf("failed to decode the versions: %w", err)
+		}
+	}
+	for version := range versions {
+		if version != stableTagTrunk {
+			history.Releases++
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// outputRenames maps the names of output columns and JSON keys to the names written instead
+type outputRenames struct {
+	// Columns maps CSV/XLSX header names, including passthrough columns
+	Columns map[string]string
+	// Keys maps the top-level keys of the JSON objects
+	Keys map[string]string
+}
+
+// renames is the -rename mapping applied to the output headers and JSON keys
+var renames outputRenames
+
+// jsonKeys are the top-level JSON keys of PluginMeta, in field order
+var jsonKeys = func() []string {
+	var keys []string
+	t := reflect.TypeOf(PluginMeta{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonKey(t.Field(i)); key != "-" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}()
+
+// jsonKey returns the JSON key of a struct field
+func jsonKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// resolveRenames resolves -rename pairs of the form source=target. A source names a PluginMeta field
+// (by Go name, CSV column or JSON key, case-insensitively), which renames both its column and JSON key,
+// or any other output or passthrough column, which renames only that column
+func resolveRenames(pairs []string, passthrough []string) (outputRenames, error) {
+	r := outputRenames{Columns: map[string]string{}, Keys: map[string]string{}}
+	t := reflect.TypeOf(PluginMeta{})
+	columns := append(slices.Clone(outputHeaders), passthrough...)
+	for _, pair := range pairs {
+		source, target, ok := strings.Cut(pair, "=")
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		if !ok || source == "" || target == "" {
+			return r, fmt.Errorf("%q is not of the form source=target", pair)
+		}
+
+		if field, ok := findField(t, source); ok {
+			if key := jsonKey(field); key != "-" {
+				r.Keys[key] = target
+			}
+			if name := field.Tag.Get("csv"); name != "" && name != "-" {
+				r.Columns[name] = target
+			}
+			continue
+		}
+		i := slices.IndexFunc(columns, func(c string) bool { return strings.EqualFold(c, source) })
+		if i < 0 {
+			return r, fmt.Errorf("unknown field or column %q", source)
+		}
+		r.Columns[columns[i]] = target
+	}
+
+	// Two columns or keys of the same name would be ambiguous to every reader, -baseline included
+	if err := checkRenameCollisions(columns, r.Columns, "columns"); err != nil {
+		return r, err
+	}
+	if err := checkRenameCollisions(jsonKeys, r.Keys, "JSON keys"); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// checkRenameCollisions returns an error when renaming names according to mapping gives two of them the
+// same name: a target that is another name kept as is, or the target of another rename. Swapping two
+// names is fine
+func checkRenameCollisions(names []string, mapping map[string]string, what string) error {
+	renamed := make(map[string]string, len(names))
+	for _, name := range names {
+		final := name
+		if target, ok := mapping[name]; ok {
+			final = target
+		}
+		if other, ok := renamed[final]; ok {
+			return fmt.Errorf("%s %q and %q would both be named %q", what, other, name, final)
+		}
+		renamed[final] = name
+	}
+	return nil
+}
+
+// findField returns the top-level field of struct type t whose Go name, CSV column or JSON key is name
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.EqualFold(field.Name, name) || strings.EqualFold(field.Tag.Get("csv"), name) || strings.EqualFold(jsonKey(field), name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// renameColumns returns headers with the -rename mapping applied
+func renameColumns(headers []string) []string {
+	for i, name := range headers {
+		if target, ok := renames.Columns[name]; ok {
+			headers[i] = target
+		}
+	}
+	return headers
+}
+
+// marshalPlugin marshals a plugin as an indented JSON object for the JSON output, starting each
+// line after the first with prefix and renaming its keys according to the -rename mapping.
+// With -json-provenance every value is wrapped with its source
+func marshalPlugin(meta PluginMeta, prefix string) ([]byte, error) {
+	if len(renames.Keys) == 0 && !jsonProvenance {
+		return json.MarshalIndent(meta, prefix, "  ")
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	var sources map[string]string
+	if jsonProvenance {
+		sources = fieldSources(meta)
+	}
+	// Rebuild the object in field order, since a map would sort the keys
+	var compact bytes.Buffer
+	compact.WriteByte('{')
+	for _, key := range jsonKeys {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		if compact.Len() > 1 {
+			compact.WriteByte(',')
+		}
+		if sources != nil {
+			if value, err = json.Marshal(provenanceValue{Value: value, Source: sources[key]}); err != nil {
+				return nil, err
+			}
+		}
+		if target, ok := renames.Keys[key]; ok {
+			key = target
+		}
+		name, _ := json.Marshal(key)
+		compact.Write(name)
+		compact.WriteByte(':')
+		compact.Write(value)
+	}
+	compact.WriteByte('}')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, compact.Bytes(), prefix, "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// readReplay reads previously exported plugin records for -replay: a JSON array as written by -format json,
+// NDJSON (one object per line) or a directory of -json-per-file files, including its subdirectories. Files ending in .gz are decompressed.
+// Keys renamed with -rename aren't recognized, so an unknown key is an error rather than a silently empty field
+func readReplay(path string) ([]PluginMeta, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readReplayFile(path)
+	}
+
+	// -shard-dirs spreads the files over subdirectories, so the whole tree is read
+	var files []string
+	err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && (strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz")) {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var records []PluginMeta
+	for _, file := range files {
+		r, err := readReplayFile(file)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r...)
+	}
+	return records, nil
+}
+
+// readReplayFile reads the plugin records of a single JSON or NDJSON file
+func readReplayFile(filename string) ([]PluginMeta, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	dec.DisallowUnknownFields()
+
+	var records []PluginMeta
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if first == '[' {
+		if err := dec.Decode(&records); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		return records, nil
+	}
+	for {
+		var meta PluginMeta
+		err := dec.Decode(&meta)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: record %d: %v", filename, len(records)+1, err)
+		}
+		records = append(records, meta)
+	}
+}
+
+// peekNonSpace returns the first byte of r that isn't whitespace, without consuming it
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			return b, r.UnreadByte()
+		}
+	}
+}
+
+// replayResults turns replayed records into scrape results, so they are exported like freshly scraped rows
+func replayResults(records []PluginMeta) []urlResult {
+	results := make([]urlResult, len(records))
+	for i, meta := range records {
+		results[i] = urlResult{URL: meta.URL, Meta: meta, Keep: true}
+	}
+	return results
+}
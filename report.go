@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// errorsReportFile is where URLs that could not be scraped are recorded
+const errorsReportFile = "plugin_meta_errors.csv"
+
+// scrapeFailure records a URL that could not be scraped and why
+type scrapeFailure struct {
+	URL string
+	Err error
+}
+
+// exportErrorsReport writes the failed URLs with their error category and message to a CSV file.
+// The URL is the first column so the report can be fed back in with -only-failed.
+// When there are no failures, a stale report from a previous run is removed
+func exportErrorsReport(failures []scrapeFailure, filename string) error {
+	if len(failures) == 0 {
+		if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		writer := csv.NewWriter(w)
+
+		if err := writer.Write([]string{"URL", "Category", "Error"}); err != nil {
+			return err
+		}
+		for _, f := range failures {
+			if err := writer.Write([]string{f.URL, errorCategory(f.Err), f.Err.Error()}); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+// mergeIntoCSV merges rows into an existing results CSV, replacing the rows of the same plugin
+// and appending new ones. The file is created when it doesn't exist yet
+func mergeIntoCSV(data []PluginMeta, filename string) error {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return exportToCSV(data, filename)
+	}
+	if err != nil {
+		return err
+	}
+	records, err := newResultsCSVReader(file).ReadAll()
+	file.Close()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return exportToCSV(data, filename)
+	}
+	if headers := headerRow(); len(records[0]) != len(headers) {
+		return fmt.Errorf("%s has %d columns, expected %d; re-run without -only-failed", filename, len(records[0]), len(headers))
+	}
+
+	rowIndex := make(map[string]int, len(records))
+	for i, record := range records[1:] {
+		rowIndex[urlKey(record[0])] = i + 1
+	}
+	for _, item := range data {
+		row := pluginRow(item)
+		key := pluginKey(item)
+		if i, ok := rowIndex[key]; ok {
+			records[i] = row
+		} else {
+			records = append(records, row)
+			rowIndex[key] = len(records) - 1
+		}
+	}
+
+	log.Printf("Merged %d rows into %s", len(data), filename)
+
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		writer := newResultsCSVWriter(w)
+		for _, record := range records {
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
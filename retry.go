@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryBackoffBase is the first backoff wait when a throttled response carries no Retry-After header;
+// each further attempt doubles it
+const retryBackoffBase = 30 * time.Second
+
+// parseRetryAfter parses a Retry-After header value in either the delta-seconds ("120")
+// or the HTTP-date ("Wed, 21 Oct 2015 07:28:00 GMT") form into a wait relative to now
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// retryWait returns how long to wait before retrying err, or false if err is not worth retrying.
+// 429 and 503 responses are retried after their Retry-After delay, or with exponential backoff
+// (30-60s, then 60-120s, ...) when the header is absent; either way the wait is capped at maxWait
+func retryWait(err error, attempt int, maxWait time.Duration) (time.Duration, bool) {
+	var statusErr *httpStatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests && statusErr.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+
+	wait, ok := parseRetryAfter(statusErr.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		backoff := retryBackoffBase << min(attempt, 16)
+		wait = backoff + time.Duration(rand.Int63n(int64(backoff)))
+	}
+	return min(wait, maxWait), true
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// jsonSchemaDraft is the JSON Schema dialect of the generated schema
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// printJSONSchema writes a JSON Schema describing the JSON output (an array of PluginMeta objects)
+func printJSONSchema(w io.Writer) error {
+	schema := map[string]interface{}{
+		"$schema":     jsonSchemaDraft,
+		"title":       "WordPress plugin metadata",
+		"description": "Plugin metadata scraped from wordpress.org, as written by -format json",
+		"type":        "array",
+		"items":       jsonSchemaOf(reflect.TypeOf(PluginMeta{})),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// jsonSchemaProvider is implemented by output types whose JSON value isn't what their Go kind suggests,
+// such as a time written as a string
+type jsonSchemaProvider interface {
+	JSONSchema() map[string]interface{}
+}
+
+// jsonSchemaProviderType is the reflect.Type of jsonSchemaProvider
+var jsonSchemaProviderType = reflect.TypeOf((*jsonSchemaProvider)(nil)).Elem()
+
+// jsonSchemaOf builds the JSON Schema of a Go type as encoding/json serializes it.
+// Struct properties are named by their json tags and described by their desc tags
+func jsonSchemaOf(t reflect.Type) map[string]interface{} {
+	if t.Kind() != reflect.Pointer && t.Implements(jsonSchemaProviderType) {
+		return reflect.Zero(t).Interface().(jsonSchemaProvider).JSONSchema()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem())}
+	case reflect.Pointer:
+		return jsonSchemaOf(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			property := jsonSchemaOf(field.Type)
+			if desc, ok := field.Tag.Lookup("desc"); ok {
+				property["description"] = desc
+			}
+			if defaultVal, ok := field.Tag.Lookup("default"); ok {
+				property["default"] = defaultVal
+			}
+			properties[name] = property
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// listFields writes a table of the PluginMeta fields (-list-fields): the Go field name used by
+// -require-fields, -group-by, -rename and -template, the JSON type of its value, its default and its
+// description, all taken from the struct definition
+func listFields(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Field\tType\tDefault\tDescription\n")
+	t := reflect.TypeOf(PluginMeta{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if jsonKey(field) == "-" {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", field.Name, fieldTypeName(field.Type), field.Tag.Get("default"), field.Tag.Get("desc"))
+	}
+	return tw.Flush()
+}
+
+// fieldTypeName names the JSON type of a field's value as in the JSON Schema, e.g. integer or
+// string (date), and array of string for slices
+func fieldTypeName(t reflect.Type) string {
+	schema := jsonSchemaOf(t)
+	name, _ := schema["type"].(string)
+	if format, ok := schema["format"].(string); ok {
+		name += " (" + format + ")"
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		if item, ok := items["type"].(string); ok {
+			name += " of " + item
+		}
+	}
+	return name
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pluginTitleSelector locates the plugin name
+const pluginTitleSelector = "h1.plugin-title"
+
+// metaItemsSelector locates the items of the metadata widget (Version, Last updated, ...)
+const metaItemsSelector = "div.entry-meta > div.widget.plugin-meta > ul > li"
+
+// fieldSelector is a selector -dump-selectors checks, with the PluginMeta field it feeds. Label, if set,
+// picks the metadata widget item whose text contains it, as scrapePluginMeta does
+type fieldSelector struct {
+	Field    string
+	Selector string
+	Label    string
+}
+
+// fieldSelectors are the selectors the extraction relies on, in output column order.
+// Keep them in step with the extract functions
+var fieldSelectors = []fieldSelector{
+	{Field: "Name", Selector: pluginTitleSelector},
+	{Field: "Version", Selector: metaItemsSelector, Label: "Version"},
+	{Field: "LastUpdated", Selector: metaItemsSelector, Label: "Last updated"},
+	{Field: "Installs", Selector: metaItemsSelector, Label: "Active installations"},
+	{Field: "WPVersion", Selector: metaItemsSelector, Label: "WordPress version"},
+	{Field: "TestedUpTo", Selector: metaItemsSelector, Label: "Tested up to"},
+	{Field: "StableTag", Selector: "a.plugin-download"},
+	{Field: "HomepageURL", Selector: ".plugin-header a[href], .entry-meta a[href]"},
+	{Field: "PHPVersion", Selector: metaItemsSelector, Label: "PHP version"},
+	{Field: "Languages", Selector: metaItemsSelector, Label: "Languages"},
+	{Field: "Tags", Selector: metaItemsSelector, Label: "Tags"},
+	{Field: "IconURL", Selector: "img.plugin-icon"},
+	{Field: "BannerURL", Selector: ".plugin-banner"},
+	{Field: "DonateURL", Selector: ".plugin-donate a[href]"},
+	{Field: "UntestedWarning", Selector: ".plugin-notice"},
+	{Field: "Description", Selector: descriptionSelector},
+	{Field: "IsFreemium", Selector: freemiumBadgeSelector},
+	{Field: "CompatibilityVotes", Selector: ".compatibility, #plugin-compatibility"},
+	{Field: "SupportStats", Selector: ".plugin-support"},
+	{Field: "FAQ", Selector: "#faq dt, #tab-faq dt, .plugin-faq dt"},
+	{Field: "PreviousVersions", Selector: "select.previous-versions option"},
+}
+
+// dumpSelectorsTextMax caps the matched text printed by -dump-selectors
+const dumpSelectorsTextMax = 60
+
+// dumpSelectors fetches the plugin page at url and writes, for every field selector, whether it matched
+// and what its first match holds (see selectionSummary). It is a diagnostic for pinpointing the selector
+// a wordpress.org redesign broke
+func dumpSelectors(w io.Writer, url string) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid HTTP status: %d", resp.StatusCode)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// The metadata widget items are listed by label, so their selector is printed once
+	fmt.Fprintf(w, "Meta items: %s (%d matched)\n\n", metaItemsSelector, doc.Find(metaItemsSelector).Length())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Field\tSelector\tMatches\tText\n")
+	for _, fs := range fieldSelectors {
+		matches := doc.Find(fs.Selector)
+		selector := fs.Selector
+		if fs.Label != "" {
+			matches = matches.FilterFunction(func(i int, s *goquery.Selection) bool {
+				return strings.Contains(s.Text(), fs.Label)
+			})
+			selector = fmt.Sprintf("meta item %q", fs.Label)
+		}
+		text := "-"
+		if matches.Length() > 0 {
+			text = selectionSummary(matches.First())
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", fs.Field, selector, matches.Length(), text)
+	}
+	return tw.Flush()
+}
+
+// selectionSummary returns what s contributes to its field, shortened to dumpSelectorsTextMax characters:
+// the link or image URL of <a> and <img> elements, the <strong> value of metadata items, otherwise the
+// whitespace-normalized text (or the style attribute of elements without text)
+func selectionSummary(s *goquery.Selection) string {
+	var text string
+	switch {
+	case s.Is("a[href]"):
+		text, _ = s.Attr("href")
+	case s.Is("img"):
+		text, _ = s.Attr("src")
+	case s.Find("strong").Length() > 0:
+		text = extractStrong(s)
+	default:
+		text = strings.Join(strings.Fields(s.Text()), " ")
+		if text == "" {
+			text, _ = s.Attr("style")
+		}
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "(empty)"
+	}
+	if utf8.RuneCountInString(text) > dumpSelectorsTextMax {
+		text = string([]rune(text)[:dumpSelectorsTextMax-3]) + "..."
+	}
+	return text
+}
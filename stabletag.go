@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// stableTagTrunk is the Stable tag of plugins released straight from trunk, whose download is <slug>.zip
+const stableTagTrunk = "trunk"
+
+// extractStableTag extracts the readme's Stable tag from the download button. wordpress.org builds its
+// link from the Stable tag, e.g. https://downloads.wordpress.org/plugin/akismet.5.3.zip for 5.3 and
+// akismet.zip for trunk, while the displayed Version comes from the plugin header. It returns an empty
+// string when the page has no download link
+func extractStableTag(doc *goquery.Document) string {
+	href, ok := doc.Find("a.plugin-download").First().Attr("href")
+	if !ok {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	name, ok := strings.CutSuffix(path.Base(u.Path), ".zip")
+	if !ok {
+		return ""
+	}
+	// Slugs never contain a dot, so everything after the first one is the tag
+	if _, tag, found := strings.Cut(name, "."); found {
+		return tag
+	}
+	return stableTagTrunk
+}
+
+// stableTagMismatch reports whether the Stable tag disagrees with the displayed version, e.g. while a
+// release is half done. A trunk Stable tag has no version of its own to disagree with
+func stableTagMismatch(stableTag, version string) bool {
+	return stableTag != "" && stableTag != stableTagTrunk && version != "" && stableTag != version
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// versionStatsURL is the endpoint behind the "Advanced View" chart of active versions on a plugin page
+const versionStatsURL = "https://api.wordpress.org/stats/plugin/1.0/"
+
+// VersionStats maps plugin versions to their percentage of active installs
+type VersionStats map[string]float64
+
+// String renders the stats as "version=percent" pairs, largest share first
+func (s VersionStats) String() string {
+	return formatVersionStats(s)
+}
+
+// fetchVersionStats fetches the percentage of active installs running each version of the plugin
+func fetchVersionStats(slug string) (VersionStats, error) {
+	if slug == "" {
+		return nil, fmt.Errorf("cannot fetch version stats without a plugin slug")
+	}
+
+	resp, err := httpClient.Get(versionStatsURL + url.PathEscape(slug))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid HTTP status: %d", resp.StatusCode)
+	}
+
+	var raw map[string]json.Number
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode version stats: %w", err)
+	}
+
+	stats := make(VersionStats, len(raw))
+	for version, share := range raw {
+		v, err := share.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid share %q for version %s: %w", share, version, err)
+		}
+		stats[version] = v
+	}
+	return stats, nil
+}
+
+// formatVersionStats renders version stats as "version=percent" pairs, largest share first
+func formatVersionStats(stats map[string]float64) string {
+	versions := make([]string, 0, len(stats))
+	for version := range stats {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if stats[versions[i]] != stats[versions[j]] {
+			return stats[versions[i]] > stats[versions[j]]
+		}
+		return versions[i] < versions[j]
+	})
+
+	pairs := make([]string, len(versions))
+	for i, version := range versions {
+		pairs[i] = version + "=" + strconv.FormatFloat(stats[version], 'f', -1, 64)
+	}
+	return strings.Join(pairs, "; ")
+}
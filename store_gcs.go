@@ -0,0 +1,51 @@
+//go:build gcs
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+func init() {
+	objectStores["gs"] = newGCSSink
+}
+
+// gcsSink streams an output file to a Google Cloud Storage object. The object only appears once
+// the writer is closed; cancelling the context first discards the upload
+type gcsSink struct {
+	*storage.Writer
+	client *storage.Client
+	cancel context.CancelFunc
+}
+
+// newGCSSink starts an upload to gs://bucket/object. Credentials come from Application Default
+// Credentials (GOOGLE_APPLICATION_CREDENTIALS, gcloud auth application-default login, ...)
+func newGCSSink(u *url.URL) (outputSink, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	w := client.Bucket(u.Host).Object(strings.TrimPrefix(u.Path, "/")).NewWriter(ctx)
+	return &gcsSink{Writer: w, client: client, cancel: cancel}, nil
+}
+
+// commit finishes the upload
+func (s *gcsSink) commit() error {
+	err := s.Writer.Close()
+	s.client.Close()
+	s.cancel()
+	return err
+}
+
+// abort cancels the upload
+func (s *gcsSink) abort() {
+	s.cancel()
+	s.Writer.Close()
+	s.client.Close()
+}
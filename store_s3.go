@@ -0,0 +1,76 @@
+//go:build s3
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func init() {
+	objectStores["s3"] = newS3Sink
+}
+
+// errUploadAborted cancels an S3 upload whose output failed
+var errUploadAborted = errors.New("upload aborted")
+
+// s3Sink streams an output file to an S3 object through a multipart upload. The object only
+// appears once the upload completes; an aborted upload leaves nothing behind
+type s3Sink struct {
+	pw     *io.PipeWriter
+	done   chan error
+	cancel context.CancelFunc
+}
+
+// newS3Sink starts an upload to s3://bucket/key. Credentials and region come from the standard
+// AWS environment (AWS_ACCESS_KEY_ID, AWS_PROFILE, AWS_REGION, ...)
+func newS3Sink(u *url.URL) (outputSink, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	s := &s3Sink{pw: pw, done: make(chan error, 1), cancel: cancel}
+	go func() {
+		_, err := manager.NewUploader(s3.NewFromConfig(cfg)).Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(u.Host),
+			Key:    aws.String(strings.TrimPrefix(u.Path, "/")),
+			Body:   pr,
+		})
+		// Unblock pending writes when the upload fails early
+		pr.CloseWithError(err)
+		s.done <- err
+	}()
+	return s, nil
+}
+
+// Write streams p to the upload
+func (s *s3Sink) Write(p []byte) (int, error) {
+	return s.pw.Write(p)
+}
+
+// commit finishes the upload and waits for S3 to confirm it
+func (s *s3Sink) commit() error {
+	s.pw.Close()
+	err := <-s.done
+	s.cancel()
+	return err
+}
+
+// abort cancels the upload
+func (s *s3Sink) abort() {
+	s.pw.CloseWithError(errUploadAborted)
+	<-s.done
+	s.cancel()
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// countRow is the number of plugins sharing one value of a field
+type countRow struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// pluginSummary holds the aggregates printed by -stats-only
+type pluginSummary struct {
+	Plugins int `json:"plugins"`
+	// LastUpdatedKnown is the number of plugins whose "Last Updated" value could be parsed,
+	// the base of UpdatedLastYearPercent
+	LastUpdatedKnown       int        `json:"last_updated_known"`
+	UpdatedLastYear        int        `json:"updated_last_year"`
+	UpdatedLastYearPercent float64    `json:"updated_last_year_percent"`
+	ByInstallTier          []countRow `json:"by_install_tier"`
+	ByTestedUpTo           []countRow `json:"by_tested_up_to"`
+}
+
+// summarizePlugins computes the aggregates of the scraped plugins. Tested-up-to versions are grouped
+// by major.minor release (6.6.1 and 6.6.2 both count as 6.6); unknown values are counted as "unknown"
+func summarizePlugins(data []PluginMeta, now time.Time) pluginSummary {
+	summary := pluginSummary{Plugins: len(data)}
+	tiers := make(map[string]int)
+	tested := make(map[string]int)
+	yearAgo := now.AddDate(-1, 0, 0)
+
+	for _, meta := range data {
+		tiers[installTierGroup(meta)]++
+		tested[releaseGroup(meta.Compat.Max)]++
+
+		if t, ok := parseLastUpdated(meta.LastUpdated, now); ok {
+			summary.LastUpdatedKnown++
+			if t.After(yearAgo) {
+				summary.UpdatedLastYear++
+			}
+		}
+	}
+	if summary.LastUpdatedKnown > 0 {
+		summary.UpdatedLastYearPercent = 100 * float64(summary.UpdatedLastYear) / float64(summary.LastUpdatedKnown)
+	}
+
+	summary.ByInstallTier = sortedCounts(tiers, largerTier)
+	summary.ByTestedUpTo = sortedCounts(tested, newerVersion)
+	return summary
+}
+
+// installTierGroup returns the install tier of a plugin, or "unknown"
+func installTierGroup(meta PluginMeta) string {
+	if n, ok := meta.installCount(); ok {
+		return installTier(n)
+	}
+	return "unknown"
+}
+
+// releaseGroup returns the major.minor release of a normalized version (6.6 for 6.6.2), or "unknown"
+func releaseGroup(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	parts := strings.Split(version, ".")
+	return strings.Join(parts[:min(2, len(parts))], ".")
+}
+
+// groupDimension is a -group-by dimension: the group of a plugin and the order of the groups
+type groupDimension struct {
+	group func(meta PluginMeta) string
+	less  func(a, b string) bool
+}
+
+// largerTier orders install tiers largest first
+func largerTier(a, b string) bool {
+	x, _ := parseInstallCount(a)
+	y, _ := parseInstallCount(b)
+	return x > y
+}
+
+// newerVersion orders versions newest first
+func newerVersion(a, b string) bool {
+	return compareVersions(a, b) > 0
+}
+
+// groupDimensions are the -group-by dimensions built on the normalized fields. Versions are grouped
+// by major.minor release
+var groupDimensions = map[string]groupDimension{
+	"install-tier": {installTierGroup, largerTier},
+	"tested-up-to": {func(meta PluginMeta) string { return releaseGroup(meta.Compat.Max) }, newerVersion},
+	"wp-version":   {func(meta PluginMeta) string { return releaseGroup(meta.Compat.Min) }, newerVersion},
+	"php-version":  {func(meta PluginMeta) string { return releaseGroup(normalizeVersion(meta.PHPVersion)) }, newerVersion},
+}
+
+// resolveGroupBy returns the -group-by dimension named name. Besides the dimensions in groupDimensions,
+// any PluginMeta field can be grouped by its exported value, with the largest groups first
+func resolveGroupBy(name string) (groupDimension, error) {
+	if dim, ok := groupDimensions[strings.ToLower(name)]; ok {
+		return dim, nil
+	}
+	fields, err := resolvePluginFields([]string{name})
+	if err != nil {
+		return groupDimension{}, fmt.Errorf("unknown dimension or field %q", name)
+	}
+	field := fields[0]
+	return groupDimension{group: func(meta PluginMeta) string {
+		value := formatCell(reflect.ValueOf(meta).FieldByName(field))
+		if value == "" {
+			return "unknown"
+		}
+		return value
+	}}, nil
+}
+
+// groupPlugins counts the plugins in each group of dim
+func groupPlugins(data []PluginMeta, dim groupDimension) []countRow {
+	counts := make(map[string]int)
+	for _, meta := range data {
+		counts[dim.group(meta)]++
+	}
+	less := dim.less
+	if less == nil {
+		less = func(a, b string) bool {
+			if counts[a] != counts[b] {
+				return counts[a] > counts[b]
+			}
+			return a < b
+		}
+	}
+	return sortedCounts(counts, less)
+}
+
+// writeGroups writes the -group-by counts as CSV (a group and a plugins column) or as JSON
+func writeGroups(rows []countRow, groupBy, format, filename string) error {
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		if format == "json" {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				GroupBy string     `json:"group_by"`
+				Groups  []countRow `json:"groups"`
+			}{groupBy, rows})
+		}
+
+		writer := newResultsCSVWriter(w)
+		if err := writer.Write([]string{groupBy, "plugins"}); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writer.Write([]string{row.Value, strconv.Itoa(row.Count)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+// sortedCounts returns the counts ordered by value with less, keeping "unknown" last
+func sortedCounts(counts map[string]int, less func(a, b string) bool) []countRow {
+	rows := make([]countRow, 0, len(counts))
+	for value, count := range counts {
+		rows = append(rows, countRow{Value: value, Count: count})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i].Value, rows[j].Value
+		if a == "unknown" || b == "unknown" {
+			return b == "unknown" && a != "unknown"
+		}
+		return less(a, b)
+	})
+	return rows
+}
+
+// printSummary writes the aggregates as an aligned text table or as JSON
+func printSummary(w io.Writer, summary pluginSummary, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Plugins\t%d\n", summary.Plugins)
+	fmt.Fprintf(tw, "Updated in the last year\t%d of %d (%.1f%%)\n", summary.UpdatedLastYear, summary.LastUpdatedKnown, summary.UpdatedLastYearPercent)
+	fmt.Fprintf(tw, "\nInstall tier\tPlugins\n")
+	for _, row := range summary.ByInstallTier {
+		fmt.Fprintf(tw, "%s\t%d\n", row.Value, row.Count)
+	}
+	fmt.Fprintf(tw, "\nTested up to\tPlugins\n")
+	for _, row := range summary.ByTestedUpTo {
+		fmt.Fprintf(tw, "%s\t%d\n", row.Value, row.Count)
+	}
+	return tw.Flush()
+}
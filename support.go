@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SupportStats is the "Issues resolved in last two months" counter of the support widget
+type SupportStats struct {
+	Resolved int `json:"resolved" desc:"Support threads resolved in the last two months"`
+	Total    int `json:"total" desc:"Support threads opened in the last two months"`
+}
+
+// String returns the counter as "N of M resolved", or an empty string when the page had no counter
+func (s *SupportStats) String() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d resolved", s.Resolved, s.Total)
+}
+
+// supportCountPattern matches the support counter text, e.g. "3 out of 12"
+var supportCountPattern = regexp.MustCompile(`(\d[\d,]*) out of (\d[\d,]*)`)
+
+// extractSupport extracts the support forum link and the resolved threads counter of the support widget
+// of the page at pageURL. The link is resolved against the page URL, and only an absolute http(s) URL is
+// kept. The URL is empty and the stats nil when the page doesn't show them
+func extractSupport(doc *goquery.Document, pageURL string) (string, *SupportStats) {
+	widget := doc.Find(".plugin-support").First()
+	var supportURL string
+	widget.Find("a[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		href, _ := s.Attr("href")
+		if strings.Contains(href, "/support/plugin/") {
+			supportURL = resolveSupportURL(pageURL, strings.TrimSpace(href))
+		}
+		return supportURL == ""
+	})
+
+	m := supportCountPattern.FindStringSubmatch(strings.Join(strings.Fields(widget.Find(".counter-count").Text()), " "))
+	if m == nil {
+		return supportURL, nil
+	}
+	stats := &SupportStats{}
+	stats.Resolved, _ = strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+	stats.Total, _ = strconv.Atoi(strings.ReplaceAll(m[2], ",", ""))
+	return supportURL, stats
+}
+
+// resolveSupportURL resolves href against pageURL, or against wordpress.org for a page loaded from disk
+// (-from-dir), and returns it if the result is an absolute http(s) URL
+func resolveSupportURL(pageURL, href string) string {
+	if isLocalURL(pageURL) {
+		pageURL = "https://" + wordpressHost + "/"
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	resolved := base.ResolveReference(ref).String()
+	if !isAbsoluteHTTPURL(resolved) {
+		return ""
+	}
+	return resolved
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to -template templates in addition to the text/template builtins
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// loadOutputTemplate parses a -template file. The template is executed once per plugin with the PluginMeta as
+// its data, e.g. {{.Name}} {{.Version}}
+func loadOutputTemplate(filename string) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(filename)).Funcs(templateFuncs).ParseFiles(filename)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// exportWithTemplate returns an exporter rendering each plugin with tmpl, one block after another.
+// A filename of "-" writes to stdout
+func exportWithTemplate(tmpl *template.Template) func([]PluginMeta, string) error {
+	return func(data []PluginMeta, filename string) error {
+		render := func(w io.Writer) error {
+			for _, item := range data {
+				if err := tmpl.Execute(w, item); err != nil {
+					return fmt.Errorf("rendering %s: %v", item.URL, err)
+				}
+			}
+			return nil
+		}
+		if filename == "-" {
+			w := bufio.NewWriter(os.Stdout)
+			if err := render(w); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+		return writeFileAtomic(filename, render)
+	}
+}
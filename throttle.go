@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// throttleResumeJitter spreads the workers resuming after a pause over up to this long, so they
+// don't all send their next request in the same instant
+const throttleResumeJitter = time.Second
+
+// throttleGate pauses the whole worker pool after a throttled (429 or 503) response, so the workers
+// back off and resume together instead of each sleeping on its own schedule
+type throttleGate struct {
+	mu         sync.Mutex
+	pauseUntil time.Time
+	// limiter, if not nil, is the -workers auto limiter, reset to a single worker on every pause
+	limiter *adaptiveLimiter
+}
+
+// newThrottleGate creates an open gate
+func newThrottleGate(limiter *adaptiveLimiter) *throttleGate {
+	return &throttleGate{limiter: limiter}
+}
+
+// pause closes the gate for d, unless it is already closed for longer
+func (g *throttleGate) pause(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	until := time.Now().Add(d)
+	if !until.After(g.pauseUntil) {
+		return
+	}
+	g.pauseUntil = until
+	log.Printf("Throttled: pausing all workers for %v", d.Round(time.Second))
+	if g.limiter != nil {
+		g.limiter.reset()
+	}
+}
+
+// wait blocks while the gate is closed. A nil gate never blocks
+func (g *throttleGate) wait() {
+	if g == nil {
+		return
+	}
+	waited := false
+	for {
+		g.mu.Lock()
+		d := time.Until(g.pauseUntil)
+		g.mu.Unlock()
+		if d <= 0 {
+			break
+		}
+		// The pause may be extended while sleeping, so check again afterwards
+		time.Sleep(d)
+		waited = true
+	}
+	if waited {
+		time.Sleep(time.Duration(rand.Int63n(int64(throttleResumeJitter))))
+	}
+}
+
+// backoff pauses the pool for d and waits for the gate to open again. Without a gate only the
+// calling worker sleeps
+func (g *throttleGate) backoff(d time.Duration) {
+	if g == nil {
+		time.Sleep(d)
+		return
+	}
+	g.pause(d)
+	g.wait()
+}
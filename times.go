@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Timestamp is a point in time of the output, such as when a page was scraped. It is written in RFC 3339
+// format (UTC) to every output, and as an empty string when zero
+type Timestamp struct {
+	time.Time
+}
+
+// timestampNow returns the current time as a Timestamp, truncated to the second it is written with
+func timestampNow() Timestamp {
+	return Timestamp{time.Now().UTC().Truncate(time.Second)}
+}
+
+// String returns the time in RFC 3339 format, or an empty string when zero
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// MarshalJSON writes the time as a string, as String formats it
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON reads a time written by MarshalJSON
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*t = Timestamp{}
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return err
+	}
+	*t = Timestamp{parsed}
+	return nil
+}
+
+// JSONSchema describes the JSON value of a Timestamp
+func (Timestamp) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+// Date is a calendar day of the output, such as a release date. It is written as YYYY-MM-DD
+type Date struct {
+	time.Time
+}
+
+// String returns the day as YYYY-MM-DD, or an empty string for a nil Date
+func (d *Date) String() string {
+	if d == nil {
+		return ""
+	}
+	return d.Format(releaseDateLayout)
+}
+
+// MarshalJSON writes the day as a string, as String formats it
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a day written by MarshalJSON
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(releaseDateLayout, s)
+	if err != nil {
+		return err
+	}
+	*d = Date{parsed}
+	return nil
+}
+
+// JSONSchema describes the JSON value of a Date
+func (Date) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string", "format": "date"}
+}
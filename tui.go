@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// tuiRecentRows is how many recent completions the live view shows
+const tuiRecentRows = 10
+
+// progressReporter is told about every completed URL of a run (-tui)
+type progressReporter interface {
+	record(url string, err error, took time.Duration)
+	close()
+}
+
+// tuiRow is one completed URL shown in the live view
+type tuiRow struct {
+	URL    string
+	Status string
+	Failed bool
+	Took   time.Duration
+}
+
+// tuiView is a full-screen tview application for operators watching a long run: overall progress,
+// throughput, error count and a table of the most recent completions. Its state is only touched
+// from the application's event loop
+type tuiView struct {
+	app         *tview.Application
+	header      *tview.TextView
+	table       *tview.Table
+	stopped     chan struct{}
+	total       int
+	done        int
+	failed      int
+	start       time.Time
+	recent      []tuiRow
+	interrupted bool
+}
+
+// newTUIView starts the live view for a run of total URLs on the terminal
+func newTUIView(total int) *tuiView {
+	v := &tuiView{
+		app:     tview.NewApplication(),
+		header:  tview.NewTextView(),
+		table:   tview.NewTable().SetFixed(1, 0),
+		stopped: make(chan struct{}),
+		total:   total,
+		start:   time.Now(),
+	}
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.header, 1, 0, false).
+		AddItem(v.table, 0, 1, true)
+	v.app.SetRoot(layout, true).SetInputCapture(v.handleKey)
+	v.render()
+
+	go func() {
+		if err := v.app.Run(); err != nil {
+			log.Printf("Warning: -tui: %v", err)
+		}
+		close(v.stopped)
+	}()
+	return v
+}
+
+// record adds a completed URL to the view and redraws it
+func (v *tuiView) record(url string, err error, took time.Duration) {
+	row := tuiRow{URL: url, Status: "ok", Failed: err != nil, Took: took}
+	if err != nil {
+		row.Status = errorCategory(err)
+	}
+
+	v.update(func() {
+		v.done++
+		if row.Failed {
+			v.failed++
+		}
+		v.recent = append(v.recent, row)
+		if len(v.recent) > tuiRecentRows {
+			v.recent = v.recent[1:]
+		}
+		v.render()
+	})
+}
+
+// update runs f on the application's event loop and redraws, unless the application has stopped
+func (v *tuiView) update(f func()) {
+	done := make(chan struct{})
+	go func() {
+		v.app.QueueUpdateDraw(f)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-v.stopped:
+	}
+}
+
+// close stops the application and gives the terminal back, so the summary of the run is printed as usual
+func (v *tuiView) close() {
+	v.update(v.app.Stop)
+	<-v.stopped
+}
+
+// handleKey makes Ctrl-C interrupt the run as it does without the view: the first press stops
+// dispatching URLs and exports what was scraped, the second quits immediately
+func (v *tuiView) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() != tcell.KeyCtrlC {
+		return event
+	}
+	if v.interrupted {
+		v.app.Stop()
+		os.Exit(exitInterrupted)
+	}
+	v.interrupted = true
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		if err := p.Signal(os.Interrupt); err != nil {
+			log.Printf("Warning: -tui: could not interrupt the run: %v", err)
+		}
+	}
+	v.render()
+	return nil
+}
+
+// render fills the header and the table from the current state
+func (v *tuiView) render() {
+	elapsed := time.Since(v.start)
+	var perMinute float64
+	if elapsed > 0 {
+		perMinute = float64(v.done) / elapsed.Minutes()
+	}
+	header := fmt.Sprintf("Progress: %d/%d  Errors: %d  Throughput: %.1f/min  Elapsed: %s", v.done, v.total, v.failed, perMinute, elapsed.Round(time.Second))
+	if v.interrupted {
+		header += "  Interrupted: finishing the URLs in flight (Ctrl-C again to quit)"
+	}
+	v.header.SetText(header)
+
+	v.table.Clear()
+	for col, title := range []string{"TOOK", "STATUS", "URL"} {
+		v.table.SetCell(0, col, tview.NewTableCell(title).SetSelectable(false).SetAttributes(tcell.AttrBold))
+	}
+	for i := len(v.recent) - 1; i >= 0; i-- {
+		row := v.recent[i]
+		r := len(v.recent) - i
+		status := tview.NewTableCell(row.Status)
+		if row.Failed {
+			status.SetTextColor(tcell.ColorRed)
+		}
+		v.table.SetCell(r, 0, tview.NewTableCell(row.Took.Round(10*time.Millisecond).String()))
+		v.table.SetCell(r, 1, status)
+		v.table.SetCell(r, 2, tview.NewTableCell(row.URL).SetExpansion(1))
+	}
+}
+
+// progressLog is the fallback of -tui when stdout is not a terminal: a plain line per completed URL
+type progressLog struct {
+	out    io.Writer
+	total  int
+	done   int
+	failed int
+}
+
+// record writes a progress line for a completed URL
+func (p *progressLog) record(url string, err error, took time.Duration) {
+	p.done++
+	status := "ok"
+	if err != nil {
+		p.failed++
+		status = errorCategory(err)
+	}
+	fmt.Fprintf(p.out, "[%d/%d] %s %s (%s, %d errors so far)\n", p.done, p.total, status, url, took.Round(10*time.Millisecond), p.failed)
+}
+
+// close does nothing: every line is written as it happens
+func (p *progressLog) close() {}
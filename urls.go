@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// wordpressHost is the host of the official plugin directory
+const wordpressHost = "wordpress.org"
+
+// maxURLLength is the longest input URL accepted, set from -max-url-length (0 means no limit)
+var maxURLLength = 2048
+
+// checkInputURL reports why an input URL cell can't be a plugin URL: longer than maxURLLength, not
+// parseable, or without a host. File URLs need no host
+func checkInputURL(rawURL string) error {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return fmt.Errorf("empty URL")
+	}
+	if maxURLLength > 0 && len(rawURL) > maxURLLength {
+		return fmt.Errorf("URL is %d characters long, more than -max-url-length %d", len(rawURL), maxURLLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" && u.Scheme != "file" {
+		return fmt.Errorf("URL has no host")
+	}
+	return nil
+}
+
+// canonicalPluginURL returns the wordpress.org URL of the plugin with the given slug, e.g.
+// https://wordpress.org/plugins/akismet/, or an empty string for an empty slug
+func canonicalPluginURL(slug string) string {
+	if slug == "" {
+		return ""
+	}
+	return "https://" + wordpressHost + "/plugins/" + url.PathEscape(slug) + "/"
+}
+
+// canonicalizeURL normalizes a plugin URL so the same plugin always maps to the same string:
+// it forces https (unless enforceHTTPS is false, for plain-HTTP mirrors), lowercases the host,
+// forces a trailing slash and, for wordpress.org hosts, strips the locale subdomain
+// (or replaces it with locale when one is given) and lowercases the path, as plugin slugs are lowercase
+func canonicalizeURL(rawURL, locale string, enforceHTTPS bool) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "file" {
+		return rawURL, nil
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("URL has no host: %q", rawURL)
+	}
+
+	if enforceHTTPS {
+		u.Scheme = "https"
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	u.Host = strings.ToLower(u.Host)
+	if isWordPressHost(u.Host) {
+		u.Host = wordpressHost
+		if locale != "" {
+			u.Host = strings.ToLower(locale) + "." + wordpressHost
+		}
+		u.Path = strings.ToLower(u.Path)
+		u.RawPath = ""
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return u.String(), nil
+}
+
+// isWordPressHost reports whether host is wordpress.org or one of its subdomains (www, locale sites)
+func isWordPressHost(host string) bool {
+	return host == wordpressHost || strings.HasSuffix(host, "."+wordpressHost)
+}
+
+// isAllowedURL reports whether rawURL may be fetched: a local file when allowFiles is set (-from-dir), or
+// an http(s) URL whose host is wordpress.org, one of its subdomains, or one of allowHosts or their
+// subdomains. An allowHosts entry of * allows any host
+func isAllowedURL(rawURL string, allowHosts []string, allowFiles bool) bool {
+	if isLocalURL(rawURL) {
+		return allowFiles
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if isWordPressHost(host) {
+		return true
+	}
+	for _, allowed := range allowHosts {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAllowedURLs drops the URLs that may not be fetched (see isAllowedURL), logging each skipped URL
+func filterAllowedURLs(urls []string, allowHosts []string, allowFiles bool) []string {
+	allowed := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if isLocalURL(u) && !allowFiles {
+			log.Printf("Warning: Skipping %s: local files are only read with -from-dir", u)
+			continue
+		}
+		if !isAllowedURL(u, allowHosts, allowFiles) {
+			log.Printf("Warning: Skipping %s: host is not allowed (see -allow-host)", u)
+			continue
+		}
+		allowed = append(allowed, u)
+	}
+	return allowed
+}
+
+// pluginSlug extracts the plugin slug from a plugin page URL, e.g. https://wordpress.org/plugins/akismet/ -> akismet.
+// It falls back to the last path segment for URLs that don't follow the /plugins/<slug>/ layout. The slug is
+// taken from the canonical form of the URL (see canonicalizeURL), so every spelling of a plugin's URL gives the
+// same slug whether or not -normalize-url is set
+func pluginSlug(rawURL string) string {
+	if canonical, err := canonicalizeURL(rawURL, "", true); err == nil {
+		rawURL = canonical
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 {
+		return ""
+	}
+	last := segments[len(segments)-1]
+
+	// A page saved by -archive-dir is named after its slug, wherever the directory is
+	if u.Scheme == "file" {
+		last = strings.TrimSuffix(last, ".gz")
+		return strings.TrimSuffix(last, filepath.Ext(last))
+	}
+	for i, segment := range segments {
+		if segment == "plugins" && i+1 < len(segments) {
+			return segments[i+1]
+		}
+	}
+	return last
+}
+
+// urlKey returns the key identifying the plugin at rawURL: its slug, or the canonical URL when it has none
+func urlKey(rawURL string) string {
+	if slug := pluginSlug(rawURL); slug != "" {
+		return slug
+	}
+	if canonical, err := canonicalizeURL(rawURL, "", true); err == nil {
+		return canonical
+	}
+	return strings.TrimSpace(rawURL)
+}
+
+// nonHTTPSURLs returns the URLs of urls that aren't https URLs
+func nonHTTPSURLs(urls []string) []string {
+	var insecure []string
+	for _, rawURL := range urls {
+		if u, err := url.Parse(strings.TrimSpace(rawURL)); err != nil || u.Scheme != "https" {
+			insecure = append(insecure, rawURL)
+		}
+	}
+	return insecure
+}
+
+// isAbsoluteHTTPURL reports whether rawURL is an absolute http or https URL with a host
+func isAbsoluteHTTPURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// archivePage writes the raw HTML of the page at rawURL to dir as <slug>.html, or <slug>.html.gz when compress is set,
+// so it can be re-extracted later with -from-dir
+func archivePage(html []byte, rawURL, dir string, compress bool) error {
+	slug := pluginSlug(rawURL)
+	if slug == "" {
+		return fmt.Errorf("no slug in URL %q", rawURL)
+	}
+	filename := filepath.Join(dir, slug+".html")
+	if compress {
+		filename += ".gz"
+	}
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		_, err := w.Write(html)
+		return err
+	})
+}
+
+// isLocalURL reports whether rawURL refers to a saved page on the local filesystem
+func isLocalURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "file://")
+}
+
+// localPageURLs returns file:// URLs for the saved .html (or gzip-compressed .html.gz) pages in dir,
+// sorted by file name. Each file name (without extension) is taken as the plugin slug, e.g. akismet.html
+func localPageURLs(dir string) ([]string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(entry.Name(), ".gz")))
+		if entry.IsDir() || (ext != ".html" && ext != ".htm") {
+			continue
+		}
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(abs, entry.Name()))}
+		urls = append(urls, u.String())
+	}
+	sort.Strings(urls)
+	return urls, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// errMissingRequiredFields is returned for rows where a field listed in -require-fields could not be scraped
+var errMissingRequiredFields = errors.New("required fields missing")
+
+// resolvePluginFields maps case-insensitive field names onto PluginMeta field names,
+// returning an error for names that don't exist
+func resolvePluginFields(names []string) ([]string, error) {
+	t := reflect.TypeOf(PluginMeta{})
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		field, ok := t.FieldByNameFunc(func(n string) bool { return strings.EqualFold(n, name) })
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		resolved = append(resolved, field.Name)
+	}
+	return resolved, nil
+}
+
+// missingRequiredFields returns the fields that are empty or still hold their default value
+func missingRequiredFields(meta PluginMeta, fields []string) []string {
+	v := reflect.ValueOf(meta)
+	t := v.Type()
+	var missing []string
+	for _, name := range fields {
+		field, _ := t.FieldByName(name)
+		value := v.FieldByName(name)
+		if value.IsZero() {
+			missing = append(missing, name)
+			continue
+		}
+		if defaultVal, ok := field.Tag.Lookup("default"); ok && value.Kind() == reflect.String && value.String() == defaultVal {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// checkRequiredFields returns an error wrapping errMissingRequiredFields when meta lacks any of fields
+func checkRequiredFields(meta PluginMeta, fields []string) error {
+	missing := missingRequiredFields(meta, fields)
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errMissingRequiredFields, strings.Join(missing, ", "))
+}
+
+// healthCheckMinRows is the number of scraped rows below which the defaulted-field health check is skipped,
+// since a few plugins legitimately lacking a field would otherwise trip it
+const healthCheckMinRows = 10
+
+// defaultedFieldWarnings checks every PluginMeta field with a default tag across the scraped rows and
+// returns a warning for each field missing in more than threshold (a fraction) of them. Such a field is
+// more likely a broken selector after a wordpress.org markup change than genuinely absent data
+func defaultedFieldWarnings(data []PluginMeta, threshold float64) []string {
+	if len(data) < healthCheckMinRows {
+		return nil
+	}
+
+	var warnings []string
+	t := reflect.TypeOf(PluginMeta{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := field.Tag.Lookup("default"); !ok {
+			continue
+		}
+		missing := 0
+		for _, meta := range data {
+			if len(missingRequiredFields(meta, []string{field.Name})) > 0 {
+				missing++
+			}
+		}
+		if ratio := float64(missing) / float64(len(data)); ratio > threshold {
+			warnings = append(warnings, fmt.Sprintf("%s is missing in %d of %d scraped rows (%.0f%%); its selector may be broken by a markup change", field.Name, missing, len(data), ratio*100))
+		}
+	}
+	return warnings
+}
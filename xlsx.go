@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSheet is the name of the worksheet holding the results
+const xlsxSheet = "Plugins"
+
+// exportToXLSX exports the scraped plugin metadata to an Excel workbook with a bold header row
+// and auto-sized columns. Numeric fields are written as numbers so they sort and sum correctly in Excel
+func exportToXLSX(data []PluginMeta, filename string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxSheet); err != nil {
+		return err
+	}
+
+	headers := headerRow()
+	widths := make([]int, len(headers))
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = h
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	if err := f.SetSheetRow(xlsxSheet, "A1", &header); err != nil {
+		return err
+	}
+
+	for r, item := range data {
+		values := pluginRow(item)
+		fields := reflect.ValueOf(item)
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v
+			if i < len(outputColumns) {
+				row[i] = xlsxCellValue(fields.FieldByIndex(outputColumns[i].Index), v)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(v))
+		}
+
+		cell, err := excelize.CoordinatesToCellName(1, r+2)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(xlsxSheet, cell, &row); err != nil {
+			return err
+		}
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	lastCol, err := excelize.ColumnNumberToName(len(headers))
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(xlsxSheet, "A1", lastCol+"1", bold); err != nil {
+		return err
+	}
+
+	for i, w := range widths {
+		col, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		// Leave a little padding and cap very long values such as tag lists
+		if err := f.SetColWidth(xlsxSheet, col, col, float64(min(w+2, 80))); err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		return f.Write(w)
+	})
+}
+
+// xlsxCellValue returns the cell value of a field formatted as text: the number itself for a numeric
+// field (Language Count, Install Count, HTTP Status, ...), otherwise text. A field formatted as an empty
+// string, such as an absent count, stays an empty cell
+func xlsxCellValue(v reflect.Value, text string) interface{} {
+	if text == "" {
+		return text
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return text
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return text
+}